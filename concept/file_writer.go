@@ -0,0 +1,101 @@
+package concept
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+type jobIDKey struct{}
+
+// WithJobID returns a copy of the context carrying the ID of the job the uploads belong to
+func WithJobID(ctx context.Context, jobID string) context.Context {
+	return context.WithValue(ctx, jobIDKey{}, jobID)
+}
+
+// JobIDFromContext returns the job ID set by WithJobID, or an empty string
+func JobIDFromContext(ctx context.Context) string {
+	jobID, _ := ctx.Value(jobIDKey{}).(string)
+	return jobID
+}
+
+// FileUpdater writes the exported files into a local directory instead of sending them to S3.
+// Every file is written under a temporary name first and renamed when complete,
+// so readers of the directory never see a partial file.
+type FileUpdater struct {
+	Directory string
+	// JobSubdirectories puts the files of every job into a subdirectory named after the job ID
+	JobSubdirectories bool
+}
+
+func (u *FileUpdater) Upload(ctx context.Context, concept io.ReadSeeker, fileName, tid string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return writeFile(u.liveDirectory(ctx), fileName, concept)
+}
+
+// Remove deletes the files from the output directory, trying all of them even if some fail
+func (u *FileUpdater) Remove(ctx context.Context, fileNames []string, tid string) error {
+	dir := u.liveDirectory(ctx)
+	var errs []error
+	for _, fileName := range fileNames {
+		if err := os.Remove(filepath.Join(dir, filepath.FromSlash(fileName))); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (u *FileUpdater) liveDirectory(ctx context.Context) string {
+	if jobID := JobIDFromContext(ctx); u.JobSubdirectories && jobID != "" {
+		return filepath.Join(u.Directory, jobID)
+	}
+	return u.Directory
+}
+
+// writeFile writes the file into the directory, or into its subdirectory if the name is a path
+func writeFile(dir, fileName string, concept io.ReadSeeker) error {
+	path := filepath.Join(dir, filepath.FromSlash(fileName))
+	dir, fileName = filepath.Dir(path), filepath.Base(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+fileName+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = concept.Seek(0, io.SeekStart); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err = io.Copy(tmp, concept); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, fileName))
+}
+
+func (u *FileUpdater) CheckHealth() (string, error) {
+	info, err := os.Stat(u.Directory)
+	if os.IsNotExist(err) {
+		// the directory is created by the first upload
+		return "Output directory will be created.", nil
+	}
+	if err != nil {
+		return "Output directory can't be accessed.", err
+	}
+	if !info.IsDir() {
+		return "Output directory is not a directory.", fmt.Errorf("%v is not a directory", u.Directory)
+	}
+	return "Output directory is accessible.", nil
+}
@@ -0,0 +1,109 @@
+package concept
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileUpdaterUpload(t *testing.T) {
+	dir := t.TempDir()
+	updater := &FileUpdater{Directory: dir}
+
+	err := updater.Upload(WithJobID(context.Background(), "job_1"), bytes.NewReader([]byte("test")), "Brand.csv", "tid_1234")
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(dir, "Brand.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, "test", string(content))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(entries), "temporary file left behind")
+}
+
+func TestFileUpdaterUploadOverwrites(t *testing.T) {
+	dir := t.TempDir()
+	updater := &FileUpdater{Directory: dir}
+
+	require.NoError(t, updater.Upload(context.Background(), bytes.NewReader([]byte("old")), "Brand.csv", "tid_1234"))
+	require.NoError(t, updater.Upload(context.Background(), bytes.NewReader([]byte("new")), "Brand.csv", "tid_1234"))
+
+	content, err := os.ReadFile(filepath.Join(dir, "Brand.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(content))
+}
+
+func TestFileUpdaterUploadToJobSubdirectory(t *testing.T) {
+	dir := t.TempDir()
+	updater := &FileUpdater{Directory: dir, JobSubdirectories: true}
+
+	err := updater.Upload(WithJobID(context.Background(), "job_1"), bytes.NewReader([]byte("test")), "Brand.csv", "tid_1234")
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(dir, "job_1", "Brand.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, "test", string(content))
+}
+
+func TestFileUpdaterUploadWithPath(t *testing.T) {
+	dir := t.TempDir()
+	updater := &FileUpdater{Directory: dir}
+	ctx := WithJobID(context.Background(), "job_1")
+
+	require.NoError(t, updater.Upload(ctx, bytes.NewReader([]byte("brands")), "reexport/2019/Brand.csv", "tid_1234"))
+	require.NoError(t, updater.Upload(ctx, bytes.NewReader([]byte("topics")), "reexport/2019/Topic.csv", "tid_1234"))
+
+	for name, expected := range map[string]string{"Brand.csv": "brands", "Topic.csv": "topics"} {
+		content, err := os.ReadFile(filepath.Join(dir, "reexport", "2019", name))
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(content))
+	}
+}
+
+func TestFileUpdaterUploadCancelled(t *testing.T) {
+	dir := t.TempDir()
+	updater := &FileUpdater{Directory: dir}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := updater.Upload(ctx, bytes.NewReader([]byte("test")), "Brand.csv", "tid_1234")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = os.Stat(filepath.Join(dir, "Brand.csv"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFileUpdaterRemove(t *testing.T) {
+	dir := t.TempDir()
+	updater := &FileUpdater{Directory: dir, JobSubdirectories: true}
+	ctx := WithJobID(context.Background(), "job_1")
+
+	require.NoError(t, updater.Upload(ctx, bytes.NewReader([]byte("brands")), "reexport/Brand.csv", "tid_1234"))
+	require.NoError(t, updater.Upload(ctx, bytes.NewReader([]byte("topics")), "reexport/Topic.csv", "tid_1234"))
+	require.NoError(t, updater.Remove(ctx, []string{"reexport/Brand.csv", "reexport/Person.csv"}, "tid_1234"), "a missing file should be skipped")
+
+	_, err := os.Stat(filepath.Join(dir, "job_1", "reexport", "Brand.csv"))
+	assert.True(t, os.IsNotExist(err))
+	content, err := os.ReadFile(filepath.Join(dir, "job_1", "reexport", "Topic.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, "topics", string(content))
+}
+
+func TestFileUpdaterCheckHealth(t *testing.T) {
+	dir := t.TempDir()
+
+	resp, err := (&FileUpdater{Directory: dir}).CheckHealth()
+	assert.NoError(t, err)
+	assert.Equal(t, "Output directory is accessible.", resp)
+
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, []byte("test"), 0644))
+	_, err = (&FileUpdater{Directory: file}).CheckHealth()
+	assert.Error(t, err)
+}
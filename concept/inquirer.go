@@ -1,6 +1,8 @@
 package concept
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -11,9 +13,10 @@ import (
 type State string
 
 const (
-	STARTING State = "Starting"
-	RUNNING  State = "Running"
-	FINISHED State = "Finished"
+	STARTING  State = "Starting"
+	RUNNING   State = "Running"
+	FINISHED  State = "Finished"
+	CANCELLED State = "Cancelled"
 )
 
 type Worker struct {
@@ -40,19 +43,20 @@ func (w *Worker) GetCount() int {
 }
 
 type Inquirer interface {
-	Inquire(candidates []string, tid string) []*Worker
+	Inquire(ctx context.Context, candidates []string, opts db.ReadOptions, tid string) []*Worker
 }
 
 type NeoInquirer struct {
-	Neo db.Service
-	Log *logger.UPPLogger
+	Neo                   db.Service
+	NrOfConcurrentWorkers int
+	Log                   *logger.UPPLogger
 }
 
-func NewNeoInquirer(neo db.Service, log *logger.UPPLogger) *NeoInquirer {
-	return &NeoInquirer{Neo: neo, Log: log}
+func NewNeoInquirer(neo db.Service, nrOfWorkers int, log *logger.UPPLogger) *NeoInquirer {
+	return &NeoInquirer{Neo: neo, NrOfConcurrentWorkers: nrOfWorkers, Log: log}
 }
 
-func (n *NeoInquirer) Inquire(candidates []string, tid string) []*Worker {
+func (n *NeoInquirer) Inquire(ctx context.Context, candidates []string, opts db.ReadOptions, tid string) []*Worker {
 	var workers []*Worker
 	for _, cType := range candidates {
 		worker := &Worker{ConceptType: cType, Errch: make(chan error, 2), ConceptCh: make(chan db.Concept), Status: STARTING}
@@ -61,23 +65,54 @@ func (n *NeoInquirer) Inquire(candidates []string, tid string) []*Worker {
 	go func() {
 		logEntry := n.Log.WithTransactionID(tid)
 		logEntry.Infof("Starting reading concepts from Neo: %v", candidates)
+		// Reads are started in the order of the workers, the same order the exporter drains them,
+		// so a read waiting for its consumer never holds the slot of a read the exporter is waiting for.
+		poolSize := n.NrOfConcurrentWorkers
+		if poolSize < 1 {
+			poolSize = 1
+		}
+		sem := make(chan struct{}, poolSize)
+		var wg sync.WaitGroup
 		for _, worker := range workers {
-			count, found, err := n.Neo.Read(worker.ConceptType, worker.ConceptCh)
-			if err != nil {
-				logEntry.WithError(err).Errorf("error by reading %v concept type from Neo", worker.ConceptType)
-				worker.Errch <- err
-				continue
-			}
-			if !found {
-				err = fmt.Errorf("reading %v concept type from Neo returned empty result", worker.ConceptType)
-				logEntry.Error(err)
-				worker.Errch <- err
-				continue
-			}
-			logEntry.Infof("Found %v entries for %v concept", count, worker.ConceptType)
-			worker.setCount(count)
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(w *Worker) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				n.read(ctx, w, opts, logEntry)
+			}(worker)
 		}
+		wg.Wait()
 		logEntry.Info("Finished Neo read")
 	}()
 	return workers
 }
+
+// read streams the concepts of the worker's type into its concept channel.
+// The error channel is closed only after the outcome of the read is known, so consumers
+// can drain the concept channel first and then check whether the read has failed.
+func (n *NeoInquirer) read(ctx context.Context, worker *Worker, opts db.ReadOptions, logEntry *logger.LogEntry) {
+	defer close(worker.Errch)
+	count, found, err := n.Neo.Read(ctx, worker.ConceptType, opts, worker.ConceptCh)
+	if errors.Is(err, context.Canceled) {
+		logEntry.Infof("Reading %v concept type from Neo was cancelled", worker.ConceptType)
+		worker.Errch <- err
+		return
+	}
+	if err != nil {
+		logEntry.WithError(err).Errorf("error by reading %v concept type from Neo", worker.ConceptType)
+		worker.Errch <- err
+		return
+	}
+	// a delta without changes is not an error, its files are uploaded empty
+	if !found && opts.Since == nil {
+		err = fmt.Errorf("reading %v concept type from Neo returned empty result", worker.ConceptType)
+		logEntry.Error(err)
+		worker.Errch <- err
+		return
+	}
+	logEntry.Infof("Found %v entries for %v concept", count, worker.ConceptType)
+	worker.setCount(count)
+}
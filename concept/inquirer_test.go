@@ -1,7 +1,9 @@
 package concept
 
 import (
+	"context"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -16,8 +18,8 @@ type mockDbService struct {
 	mock.Mock
 }
 
-func (m *mockDbService) Read(conceptType string, conceptCh chan db.Concept) (int, bool, error) {
-	args := m.Called(conceptType, conceptCh)
+func (m *mockDbService) Read(ctx context.Context, conceptType string, opts db.ReadOptions, conceptCh chan db.Concept) (int, bool, error) {
+	args := m.Called(ctx, conceptType, opts, conceptCh)
 	return args.Int(0), args.Bool(1), args.Error(2)
 }
 
@@ -25,12 +27,12 @@ func TestNeoInquirer_InquireSuccessfully(t *testing.T) {
 	log := logger.NewUPPLogger("Test", "PANIC")
 
 	mockDb := new(mockDbService)
-	inquirer := NewNeoInquirer(mockDb, log)
+	inquirer := NewNeoInquirer(mockDb, 1, log)
 
 	cType := "Brand"
-	mockDb.On("Read", cType, mock.AnythingOfType("chan db.Concept")).Return(2, true, nil)
+	mockDb.On("Read", mock.Anything, cType, db.ReadOptions{}, mock.AnythingOfType("chan db.Concept")).Return(2, true, nil)
 
-	workers := inquirer.Inquire([]string{cType}, "tid_1234")
+	workers := inquirer.Inquire(context.Background(), []string{cType}, db.ReadOptions{}, "tid_1234")
 
 	time.Sleep(500 * time.Millisecond)
 
@@ -39,6 +41,8 @@ func TestNeoInquirer_InquireSuccessfully(t *testing.T) {
 	assert.Equal(t, 2, workers[0].GetCount())
 	assert.Equal(t, STARTING, workers[0].Status)
 	assert.Equal(t, 0, len(workers[0].Errch))
+	_, open := <-workers[0].Errch
+	assert.False(t, open)
 	mockDb.AssertExpectations(t)
 }
 
@@ -46,12 +50,12 @@ func TestNeoInquirer_InquireSuccessfullyWithEmptyResult(t *testing.T) {
 	log := logger.NewUPPLogger("Test", "PANIC")
 
 	mockDb := new(mockDbService)
-	inquirer := NewNeoInquirer(mockDb, log)
+	inquirer := NewNeoInquirer(mockDb, 1, log)
 
 	cType := "Brand"
-	mockDb.On("Read", cType, mock.AnythingOfType("chan db.Concept")).Return(0, false, nil)
+	mockDb.On("Read", mock.Anything, cType, db.ReadOptions{}, mock.AnythingOfType("chan db.Concept")).Return(0, false, nil)
 
-	workers := inquirer.Inquire([]string{cType}, "tid_1234")
+	workers := inquirer.Inquire(context.Background(), []string{cType}, db.ReadOptions{}, "tid_1234")
 
 	time.Sleep(500 * time.Millisecond)
 
@@ -64,16 +68,36 @@ func TestNeoInquirer_InquireSuccessfullyWithEmptyResult(t *testing.T) {
 	mockDb.AssertExpectations(t)
 }
 
+func TestNeoInquirer_InquireDeltaWithEmptyResult(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	mockDb := new(mockDbService)
+	inquirer := NewNeoInquirer(mockDb, 1, log)
+
+	cType := "Brand"
+	opts := db.ReadOptions{Since: &time.Time{}}
+	mockDb.On("Read", mock.Anything, cType, opts, mock.AnythingOfType("chan db.Concept")).Return(0, false, nil)
+
+	workers := inquirer.Inquire(context.Background(), []string{cType}, opts, "tid_1234")
+
+	time.Sleep(500 * time.Millisecond)
+
+	assert.Equal(t, 1, len(workers))
+	assert.Equal(t, 0, workers[0].GetCount())
+	assert.Equal(t, 0, len(workers[0].Errch))
+	mockDb.AssertExpectations(t)
+}
+
 func TestNeoInquirer_InquireWithError(t *testing.T) {
 	log := logger.NewUPPLogger("Test", "PANIC")
 
 	mockDb := new(mockDbService)
-	inquirer := NewNeoInquirer(mockDb, log)
+	inquirer := NewNeoInquirer(mockDb, 1, log)
 
 	cType := "Brand"
-	mockDb.On("Read", cType, mock.AnythingOfType("chan db.Concept")).Return(0, false, errors.New("Neo err"))
+	mockDb.On("Read", mock.Anything, cType, db.ReadOptions{}, mock.AnythingOfType("chan db.Concept")).Return(0, false, errors.New("Neo err"))
 
-	workers := inquirer.Inquire([]string{cType}, "tid_1234")
+	workers := inquirer.Inquire(context.Background(), []string{cType}, db.ReadOptions{}, "tid_1234")
 
 	time.Sleep(500 * time.Millisecond)
 
@@ -85,3 +109,51 @@ func TestNeoInquirer_InquireWithError(t *testing.T) {
 	assert.Equal(t, "Neo err", (<-workers[0].Errch).Error())
 	mockDb.AssertExpectations(t)
 }
+
+func TestNeoInquirer_InquireConcurrently(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	mockDb := new(mockDbService)
+	inquirer := NewNeoInquirer(mockDb, 2, log)
+
+	// Each read waits for the other one to start, so they only finish if run at the same time
+	var started sync.WaitGroup
+	started.Add(2)
+	waitForOtherRead := func(mock.Arguments) {
+		started.Done()
+		started.Wait()
+	}
+	mockDb.On("Read", mock.Anything, "Brand", db.ReadOptions{}, mock.AnythingOfType("chan db.Concept")).Run(waitForOtherRead).Return(1, true, nil)
+	mockDb.On("Read", mock.Anything, "Topic", db.ReadOptions{}, mock.AnythingOfType("chan db.Concept")).Run(waitForOtherRead).Return(2, true, nil)
+
+	workers := inquirer.Inquire(context.Background(), []string{"Brand", "Topic"}, db.ReadOptions{}, "tid_1234")
+
+	time.Sleep(500 * time.Millisecond)
+
+	assert.Equal(t, 2, len(workers))
+	assert.Equal(t, 1, workers[0].GetCount())
+	assert.Equal(t, 2, workers[1].GetCount())
+	mockDb.AssertExpectations(t)
+}
+
+func TestNeoInquirer_InquireCancelled(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	mockDb := new(mockDbService)
+	inquirer := NewNeoInquirer(mockDb, 1, log)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cType := "Brand"
+	mockDb.On("Read", ctx, cType, db.ReadOptions{}, mock.AnythingOfType("chan db.Concept")).Return(0, false, context.Canceled)
+
+	workers := inquirer.Inquire(ctx, []string{cType}, db.ReadOptions{}, "tid_1234")
+
+	time.Sleep(500 * time.Millisecond)
+
+	assert.Equal(t, 1, len(workers))
+	assert.Equal(t, 0, workers[0].GetCount())
+	assert.ErrorIs(t, <-workers[0].Errch, context.Canceled)
+	mockDb.AssertExpectations(t)
+}
@@ -1,11 +1,15 @@
 package concept
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"path"
+	"strings"
+	"time"
 )
 
 const s3WriterPath = "/concept/"
@@ -14,43 +18,156 @@ type Client interface {
 	Do(req *http.Request) (resp *http.Response, err error)
 }
 
+// Updater uploads an exported file. The content is read from its start, and is read again if the upload is retried.
 type Updater interface {
-	Upload(concept []byte, conceptType, tid string) error
+	Upload(ctx context.Context, concept io.ReadSeeker, fileName, tid string) error
 }
 
+// Remover deletes uploaded files, a file which is already missing is not an error
+type Remover interface {
+	Remove(ctx context.Context, fileNames []string, tid string) error
+}
+
+// S3Updater streams the files to the S3 writer, retrying the uploads failing with an error or a 5xx response
 type S3Updater struct {
 	Client            Client
 	S3WriterBaseURL   string
 	S3WriterHealthURL string
+	MaxRetries        int
+	// Backoff returns how long to wait before the given retry, starting from 1
+	Backoff func(retry int) time.Duration
+}
+
+func (u *S3Updater) Upload(ctx context.Context, concept io.ReadSeeker, fileName, tid string) error {
+	return u.put(ctx, concept, fileName, tid)
+}
+
+// Remove deletes the files through the S3 writer, trying all of them even if some fail
+func (u *S3Updater) Remove(ctx context.Context, fileNames []string, tid string) error {
+	var errs []error
+	for _, fileName := range fileNames {
+		if err := u.delete(ctx, fileName, tid); err != nil {
+			errs = append(errs, fmt.Errorf("deleting %v failed: %w", fileName, err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
-func (u *S3Updater) Upload(concept []byte, fileName, tid string) error {
-	buf := new(bytes.Buffer)
-	_, err := buf.Write(concept)
+func (u *S3Updater) put(ctx context.Context, concept io.ReadSeeker, fileName, tid string) error {
+	for retry := 1; ; retry++ {
+		retriable, err := u.tryPut(ctx, concept, fileName, tid)
+		if err == nil || !retriable || retry > u.MaxRetries || ctx.Err() != nil {
+			return err
+		}
+		var backoff time.Duration
+		if u.Backoff != nil {
+			backoff = u.Backoff(retry)
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// tryPut streams the content from its start and tells whether a failed upload can be retried
+func (u *S3Updater) tryPut(ctx context.Context, concept io.ReadSeeker, fileName, tid string) (bool, error) {
+	size, err := concept.Seek(0, io.SeekEnd)
 	if err != nil {
-		return err
+		return false, err
+	}
+	if _, err = concept.Seek(0, io.SeekStart); err != nil {
+		return false, err
 	}
 
-	req, err := http.NewRequest("PUT", u.S3WriterBaseURL+s3WriterPath+fileName, buf)
+	// the body isn't closed by the client, the caller owns the content
+	req, err := http.NewRequestWithContext(ctx, "PUT", u.S3WriterBaseURL+s3WriterPath+fileName, io.NopCloser(concept))
 	if err != nil {
-		return err
+		return false, err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
 	}
 	req.Header.Add("User-Agent", "UPP Concept Exporter")
-	req.Header.Add("Content-Type", "application/json")
+	contentType, contentEncoding := contentHeaders(fileName)
+	req.Header.Add("Content-Type", contentType)
+	if contentEncoding != "" {
+		req.Header.Add("Content-Encoding", contentEncoding)
+	}
 	req.Header.Add("X-Request-Id", tid)
 
 	resp, err := u.Client.Do(req)
 	if err != nil {
-		return err
+		return true, err
 	}
-	defer resp.Body.Close()
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("UPP Export RW S3 returned HTTP %v", resp.StatusCode)
+		return resp.StatusCode >= 500, fmt.Errorf("UPP Export RW S3 returned HTTP %v", resp.StatusCode)
+	}
+
+	return false, nil
+}
+
+var contentEncodings = map[string]string{
+	".gz":  "gzip",
+	".zst": "zstd",
+}
+
+var contentTypes = map[string]string{
+	".csv":   "text/csv",
+	".jsonl": "application/x-ndjson",
+	".json":  "application/json",
+}
+
+// contentHeaders returns the Content-Type and Content-Encoding of a file from the extensions of its name,
+// e.g. Brand.csv.gz is a gzip encoded text/csv file
+func contentHeaders(fileName string) (contentType, contentEncoding string) {
+	ext := path.Ext(fileName)
+	if encoding, found := contentEncodings[ext]; found {
+		contentEncoding = encoding
+		ext = path.Ext(strings.TrimSuffix(fileName, ext))
 	}
+	contentType, found := contentTypes[ext]
+	if !found {
+		contentType = "application/octet-stream"
+	}
+	return contentType, contentEncoding
+}
 
+func (u *S3Updater) delete(ctx context.Context, fileName, tid string) error {
+	req, err := u.newRequest(ctx, http.MethodDelete, fileName, tid)
+	if err != nil {
+		return err
+	}
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+	if (resp.StatusCode < 200 || resp.StatusCode >= 300) && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("UPP Export RW S3 returned HTTP %v", resp.StatusCode)
+	}
 	return nil
 }
 
+func (u *S3Updater) newRequest(ctx context.Context, method, fileName, tid string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.S3WriterBaseURL+s3WriterPath+fileName, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("User-Agent", "UPP Concept Exporter")
+	req.Header.Add("X-Request-Id", tid)
+	return req, nil
+}
+
 func (u *S3Updater) CheckHealth(client Client) (string, error) {
 	req, err := http.NewRequest("GET", u.S3WriterHealthURL, nil)
 	if err != nil {
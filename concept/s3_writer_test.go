@@ -1,16 +1,21 @@
 package concept
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type mockHttpClient struct {
@@ -68,12 +73,12 @@ func TestS3UpdaterUploadConcept(t *testing.T) {
 	testConcept := "Brand"
 
 	mockServer := new(mockS3WriterServer)
-	mockServer.On("UploadRequest", testConcept+".csv", "tid_1234", "application/json").Return(200)
+	mockServer.On("UploadRequest", testConcept+".csv", "tid_1234", "text/csv").Return(200)
 	server := mockServer.startMockS3WriterServer(t)
 
 	updater := NewS3Updater(server.URL)
 
-	err := updater.Upload([]byte("test"), testConcept+".csv", "tid_1234")
+	err := updater.Upload(context.Background(), bytes.NewReader([]byte("test")), testConcept+".csv", "tid_1234")
 	assert.NoError(t, err)
 	mockServer.AssertExpectations(t)
 }
@@ -82,21 +87,70 @@ func TestS3UpdaterUploadContentErrorResponse(t *testing.T) {
 	testConcept := "Brand"
 
 	mockServer := new(mockS3WriterServer)
-	mockServer.On("UploadRequest", testConcept+".csv", "tid_1234", "application/json").Return(503)
+	mockServer.On("UploadRequest", testConcept+".csv", "tid_1234", "text/csv").Return(503)
 	server := mockServer.startMockS3WriterServer(t)
 
 	updater := NewS3Updater(server.URL)
 
-	err := updater.Upload([]byte("test"), testConcept+".csv", "tid_1234")
+	err := updater.Upload(context.Background(), bytes.NewReader([]byte("test")), testConcept+".csv", "tid_1234")
 	assert.Error(t, err)
 	assert.Equal(t, "UPP Export RW S3 returned HTTP 503", err.Error())
 	mockServer.AssertExpectations(t)
 }
 
+func TestS3UpdaterUploadCompressedContent(t *testing.T) {
+	var encoding string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+	}))
+	defer server.Close()
+
+	updater := NewS3Updater(server.URL)
+
+	err := updater.Upload(context.Background(), bytes.NewReader([]byte("test")), "Brand.jsonl.gz", "tid_1234")
+	assert.NoError(t, err)
+	assert.Equal(t, "gzip", encoding)
+}
+
+func TestContentHeaders(t *testing.T) {
+	tests := []struct {
+		fileName        string
+		contentType     string
+		contentEncoding string
+	}{
+		{"Brand.csv", "text/csv", ""},
+		{"Brand-added.jsonl", "application/x-ndjson", ""},
+		{"manifest.json", "application/json", ""},
+		{"Brand.csv.gz", "text/csv", "gzip"},
+		{"reexport/job_1/Brand.jsonl.zst", "application/x-ndjson", "zstd"},
+		{"Brand", "application/octet-stream", ""},
+	}
+	for _, test := range tests {
+		contentType, contentEncoding := contentHeaders(test.fileName)
+		assert.Equal(t, test.contentType, contentType, test.fileName)
+		assert.Equal(t, test.contentEncoding, contentEncoding, test.fileName)
+	}
+}
+
+func TestS3UpdaterUploadCancelled(t *testing.T) {
+	mockServer := new(mockS3WriterServer)
+	server := mockServer.startMockS3WriterServer(t)
+
+	updater := NewS3Updater(server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := updater.Upload(ctx, bytes.NewReader([]byte("test")), "Brand.csv", "tid_1234")
+	assert.ErrorIs(t, err, context.Canceled)
+	mockServer.AssertNotCalled(t, "UploadRequest", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestS3UpdaterUploadContentWithErrorOnNewRequest(t *testing.T) {
 	updater := NewS3Updater("://")
 
-	err := updater.Upload([]byte("test"), "Brand.csv", "tid_1234")
+	err := updater.Upload(context.Background(), bytes.NewReader([]byte("test")), "Brand.csv", "tid_1234")
 	var urlError *url.Error
 	assert.True(t, errors.As(err, &urlError))
 	assert.Equal(t, err.(*url.Error).Op, "parse")
@@ -110,12 +164,66 @@ func TestS3UpdaterUploadContentErrorOnRequestDo(t *testing.T) {
 		S3WriterBaseURL: "http://server",
 	}
 
-	err := updater.Upload([]byte("test"), "Brand.csv", "tid_1234")
+	err := updater.Upload(context.Background(), bytes.NewReader([]byte("test")), "Brand.csv", "tid_1234")
 	assert.Error(t, err)
 	assert.Equal(t, "Http Client err", err.Error())
 	mockClient.AssertExpectations(t)
 }
 
+// startMockS3WriterStore starts an S3 writer keeping the uploaded files in the store
+func startMockS3WriterStore(t *testing.T, store map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "tid_1234", r.Header.Get("X-Request-Id"))
+		path := strings.TrimPrefix(r.URL.Path, "/concept/")
+		switch r.Method {
+		case http.MethodPut:
+			body, err := ioutil.ReadAll(r.Body)
+			assert.NoError(t, err)
+			store[path] = string(body)
+		case http.MethodDelete:
+			if _, found := store[path]; !found {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			delete(store, path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+}
+
+func TestS3UpdaterUploadWithPath(t *testing.T) {
+	store := map[string]string{}
+	server := startMockS3WriterStore(t, store)
+	defer server.Close()
+	updater := NewS3Updater(server.URL)
+
+	require.NoError(t, updater.Upload(context.Background(), bytes.NewReader([]byte("brands")), "reexport/job_1/Brand.csv", "tid_1234"))
+	assert.Equal(t, map[string]string{"reexport/job_1/Brand.csv": "brands"}, store)
+}
+
+func TestS3UpdaterRemove(t *testing.T) {
+	store := map[string]string{"reexport/job_1/Brand.csv": "brands", "reexport/job_1/Topic.csv": "topics"}
+	server := startMockS3WriterStore(t, store)
+	defer server.Close()
+	updater := NewS3Updater(server.URL).(*S3Updater)
+
+	err := updater.Remove(context.Background(), []string{"reexport/job_1/Brand.csv", "reexport/job_1/Person.csv"}, "tid_1234")
+	require.NoError(t, err, "a missing file should be skipped")
+	assert.Equal(t, map[string]string{"reexport/job_1/Topic.csv": "topics"}, store)
+}
+
+func TestS3UpdaterRemoveFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	updater := NewS3Updater(server.URL).(*S3Updater)
+
+	err := updater.Remove(context.Background(), []string{"Brand.csv", "Topic.csv"}, "tid_1234")
+	assert.EqualError(t, err, "deleting Brand.csv failed: UPP Export RW S3 returned HTTP 503\ndeleting Topic.csv failed: UPP Export RW S3 returned HTTP 503")
+}
+
 func TestS3UpdaterCheckHealth(t *testing.T) {
 	mockServer := new(mockS3WriterServer)
 	mockServer.On("GTG").Return(200)
@@ -175,3 +283,46 @@ func NewS3Updater(s3WriterBaseURL string) Updater {
 		S3WriterHealthURL: s3WriterBaseURL + "/__gtg",
 	}
 }
+
+func TestS3UpdaterUploadRetries(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "test", string(body), "every attempt should send the whole file")
+		assert.Equal(t, int64(4), r.ContentLength)
+		if attempts < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var backoffs []int
+	updater := &S3Updater{Client: &http.Client{}, S3WriterBaseURL: server.URL, MaxRetries: 3, Backoff: func(retry int) time.Duration {
+		backoffs = append(backoffs, retry)
+		return time.Millisecond
+	}}
+
+	err := updater.Upload(context.Background(), bytes.NewReader([]byte("test")), "Brand.csv", "tid_1234")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []int{1, 2}, backoffs)
+}
+
+func TestS3UpdaterUploadDoesNotRetryClientErrors(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	updater := &S3Updater{Client: &http.Client{}, S3WriterBaseURL: server.URL, MaxRetries: 3}
+
+	err := updater.Upload(context.Background(), bytes.NewReader([]byte("test")), "Brand.csv", "tid_1234")
+	assert.EqualError(t, err, "UPP Export RW S3 returned HTTP 400")
+	assert.Equal(t, 1, attempts)
+}
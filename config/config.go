@@ -0,0 +1,126 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Financial-Times/concept-exporter/db"
+	"github.com/Financial-Times/concept-exporter/export"
+	"gopkg.in/yaml.v3"
+)
+
+// Config defines the concept types the service exports
+type Config struct {
+	ConceptTypes []ConceptType `json:"conceptTypes" yaml:"conceptTypes"`
+}
+
+// ConceptType defines a concept type with its query and CSV columns.
+// A concept type without a query of its own is read with its built-in query, or the generic one,
+// and one without CSV columns gets its built-in ones.
+type ConceptType struct {
+	// Name is the Neo4j label of the canonical nodes of the concept type
+	Name                string `json:"name" yaml:"name"`
+	db.ConceptTypeQuery `yaml:",inline"`
+	CSVColumns          []string `json:"csvColumns,omitempty" yaml:"csvColumns,omitempty"`
+}
+
+// Load reads the YAML (.yaml, .yml) or JSON (.json) config file and validates it
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&cfg)
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&cfg)
+	default:
+		return nil, fmt.Errorf("unsupported config file %v, it should be .yaml, .yml or .json", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing config file %v failed: %w", path, err)
+	}
+	if err = cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %v: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Validate checks that the concept types are named uniquely with valid labels and relationship types,
+// and only use the supported fields and CSV columns
+func (c *Config) Validate() error {
+	if len(c.ConceptTypes) == 0 {
+		return errors.New("no concept types")
+	}
+	names := map[string]bool{}
+	for _, t := range c.ConceptTypes {
+		if t.Name == "" {
+			return errors.New("concept type without a name")
+		}
+		if err := db.ValidateIdentifier(t.Name); err != nil {
+			return err
+		}
+		for _, predicate := range t.Predicates {
+			if err := db.ValidateIdentifier(predicate); err != nil {
+				return fmt.Errorf("%w of %v", err, t.Name)
+			}
+		}
+		if names[t.Name] {
+			return fmt.Errorf("concept type %v defined more than once", t.Name)
+		}
+		names[t.Name] = true
+		for field := range t.Fields {
+			if !db.IsMappableField(field) {
+				return fmt.Errorf("unsupported field %v of %v", field, t.Name)
+			}
+		}
+		for _, column := range t.CSVColumns {
+			if !export.IsSupportedCSVColumn(column) {
+				return fmt.Errorf("unsupported CSV column %v of %v", column, t.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Names returns the names of the concept types in the order they are defined
+func (c *Config) Names() []string {
+	var names []string
+	for _, t := range c.ConceptTypes {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// Queries returns the queries of the concept types defining one
+func (c *Config) Queries() map[string]db.ConceptTypeQuery {
+	queries := map[string]db.ConceptTypeQuery{}
+	for _, t := range c.ConceptTypes {
+		if len(t.Predicates) > 0 || t.Match != "" || len(t.Fields) > 0 {
+			queries[t.Name] = t.ConceptTypeQuery
+		}
+	}
+	return queries
+}
+
+// CSVColumns returns the CSV columns of the concept types defining them
+func (c *Config) CSVColumns() map[string][]string {
+	columns := map[string][]string{}
+	for _, t := range c.ConceptTypes {
+		if len(t.CSVColumns) > 0 {
+			columns[t.Name] = t.CSVColumns
+		}
+	}
+	return columns
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Financial-Times/concept-exporter/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoad_Example(t *testing.T) {
+	cfg, err := Load("example.yaml")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Brand", "Topic", "Location", "Person", "Organisation", "Genre", "Subject", "Section", "SpecialReport"}, cfg.Names())
+	queries := cfg.Queries()
+	assert.Equal(t, 4, len(queries), "the built-in concept types should keep their queries")
+	assert.Equal(t, []string{"IS_CLASSIFIED_BY", "IS_PRIMARILY_CLASSIFIED_BY"}, queries["Genre"].Predicates)
+	assert.Equal(t, "reduce(all = [], a IN aliases | all + a)", queries["SpecialReport"].Fields["Aliases"])
+	assert.Contains(t, queries["SpecialReport"].Match, "WITH x, Change")
+	assert.Equal(t, map[string][]string{"Genre": {"id", "prefLabel", "apiUrl"}}, cfg.CSVColumns())
+}
+
+func TestLoad_JSON(t *testing.T) {
+	path := writeConfig(t, "config.json", `{"conceptTypes": [
+		{"name": "Brand"},
+		{"name": "Genre", "predicates": ["IS_CLASSIFIED_BY"], "fields": {"ShortName": "x.shortName"}, "csvColumns": ["prefLabel"]}
+	]}`)
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Brand", "Genre"}, cfg.Names())
+	assert.Equal(t, map[string]db.ConceptTypeQuery{
+		"Genre": {Predicates: []string{"IS_CLASSIFIED_BY"}, Fields: map[string]string{"ShortName": "x.shortName"}},
+	}, cfg.Queries())
+	assert.Equal(t, map[string][]string{"Genre": {"prefLabel"}}, cfg.CSVColumns())
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := map[string]struct {
+		name    string
+		content string
+		err     string
+	}{
+		"no concept types": {
+			name:    "config.yaml",
+			content: "conceptTypes: []",
+			err:     "no concept types",
+		},
+		"no name": {
+			name:    "config.yaml",
+			content: "conceptTypes:\n  - predicates: [ABOUT]",
+			err:     "concept type without a name",
+		},
+		"duplicate": {
+			name:    "config.yml",
+			content: "conceptTypes:\n  - name: Brand\n  - name: Brand",
+			err:     "concept type Brand defined more than once",
+		},
+		"invalid name": {
+			name:    "config.yaml",
+			content: "conceptTypes:\n  - name: Special Report",
+			err:     `invalid label or relationship type "Special Report"`,
+		},
+		"invalid predicate": {
+			name:    "config.yaml",
+			content: "conceptTypes:\n  - name: Genre\n    predicates: [\"ABOUT]-()\"]",
+			err:     `invalid label or relationship type "ABOUT]-()" of Genre`,
+		},
+		"unsupported field": {
+			name:    "config.json",
+			content: `{"conceptTypes": [{"name": "Genre", "fields": {"Colour": "x.colour"}}]}`,
+			err:     "unsupported field Colour of Genre",
+		},
+		"unsupported CSV column": {
+			name:    "config.json",
+			content: `{"conceptTypes": [{"name": "Genre", "csvColumns": ["colour"]}]}`,
+			err:     "unsupported CSV column colour of Genre",
+		},
+		"unknown key": {
+			name:    "config.yaml",
+			content: "conceptTypes:\n  - name: Genre\n    query: MATCH (x)",
+			err:     "field query not found",
+		},
+		"unsupported extension": {
+			name:    "config.toml",
+			content: "",
+			err:     "it should be .yaml, .yml or .json",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, test.name, test.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Financial-Times/neo-model-utils-go/mapper"
+)
+
+// ConcordanceService reads the identifiers the canonical concepts have in the systems their source concepts come from.
+// ReadConcordances blocks until every concordance of the concepts of the given type has been sent on the channel
+// and returns the final count. It stops early with the context's error when the context is cancelled.
+type ConcordanceService interface {
+	ReadConcordances(ctx context.Context, conceptType string, opts ReadOptions, concordanceCh chan Concordance) (int, error)
+}
+
+// Concordance maps a canonical concept, identified by the ID URL of its prefUUID,
+// to the identifier of one of its source concepts in the authority it comes from, e.g. TME, Smartlogic or FACTSET
+type Concordance struct {
+	ID             string
+	Authority      string
+	AuthorityValue string
+}
+
+// concordancesRow has the identifiers of all the source concepts of a canonical concept
+type concordancesRow struct {
+	UUID        string `json:"Uuid"`
+	Identifiers []struct {
+		Authority      string `json:"authority"`
+		AuthorityValue string `json:"authorityValue"`
+	} `json:"Identifiers"`
+}
+
+// ReadConcordances reads the identifiers of the source concepts of the canonical concepts of the given type,
+// the same concepts Read returns for a full export with the given options
+func (s *NeoService) ReadConcordances(ctx context.Context, conceptType string, opts ReadOptions, concordanceCh chan Concordance) (int, error) {
+	defer close(concordanceCh)
+	opts.Since = nil
+
+	q := s.getQuery(conceptType)
+	if err := s.checkQuery(conceptType, q); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	_, err := readPages(ctx, s, getSelectionStatement(conceptType, q, opts), getConcordancesStatement(conceptType), getReadParams(opts), func(row concordancesRow) error {
+		sort.Slice(row.Identifiers, func(i, j int) bool {
+			if row.Identifiers[i].Authority != row.Identifiers[j].Authority {
+				return row.Identifiers[i].Authority < row.Identifiers[j].Authority
+			}
+			return row.Identifiers[i].AuthorityValue < row.Identifiers[j].AuthorityValue
+		})
+		for _, identifier := range row.Identifiers {
+			select {
+			case concordanceCh <- Concordance{ID: mapper.IDURL(row.UUID), Authority: identifier.Authority, AuthorityValue: identifier.AuthorityValue}:
+				count++
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// getConcordancesStatement returns the Cypher reading the identifiers of the source concepts of the canonical concepts selected for a page
+func getConcordancesStatement(conceptType string) string {
+	return getPageMatch(conceptType) + `OPTIONAL MATCH (x)<-[:EQUIVALENT_TO]-(source)
+		WITH x, collect(DISTINCT CASE WHEN source.authority IS NOT NULL AND source.authorityValue IS NOT NULL
+			THEN {authority: source.authority, authorityValue: source.authorityValue} END) AS Identifiers
+		RETURN x.prefUUID AS Uuid, Identifiers
+		ORDER BY Uuid
+		`
+}
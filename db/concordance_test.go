@@ -0,0 +1,20 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadConcordances_RejectsLabels(t *testing.T) {
+	s := NewNeoService(nil, "")
+	concordanceCh := make(chan Concordance)
+	count, err := s.ReadConcordances(context.Background(), "Brand`) DETACH DELETE (x", ReadOptions{}, concordanceCh)
+
+	assert.ErrorIs(t, err, ErrLabelNotAllowed)
+	assert.Equal(t, 0, count)
+	_, open := <-concordanceCh
+	assert.False(t, open, "the channel should be closed")
+	assert.Contains(t, getConcordancesStatement("Brand"), "MATCH (x:`Brand`)")
+}
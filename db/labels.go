@@ -0,0 +1,83 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// identifierPattern is the grammar of the labels and relationship types put into the Cypher statements
+var identifierPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,63}$`)
+
+// ErrLabelNotAllowed is returned when reading a concept type whose label was not registered
+var ErrLabelNotAllowed = errors.New("label not allowed")
+
+// DefaultLabels are the labels of the built-in concept types, allowed by every NeoService
+var DefaultLabels = []string{"Brand", "Topic", "Location", "Person", "Organisation"}
+
+// ValidateIdentifier checks that a label or relationship type is a letter followed by at most 63 letters, digits or underscores
+func ValidateIdentifier(identifier string) error {
+	if !identifierPattern.MatchString(identifier) {
+		return fmt.Errorf("invalid label or relationship type %q", identifier)
+	}
+	return nil
+}
+
+// quoteIdentifier quotes a label or relationship type with backticks, escaping the backticks it contains.
+// The identifiers are validated first, the quoting only guards against a validation being missed.
+func quoteIdentifier(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+// quoteIdentifiers quotes the relationship types and joins them into a Cypher alternation
+func quoteIdentifiers(identifiers []string) string {
+	quoted := make([]string, 0, len(identifiers))
+	for _, identifier := range identifiers {
+		quoted = append(quoted, quoteIdentifier(identifier))
+	}
+	return strings.Join(quoted, "|")
+}
+
+// RegisterLabels allows the concept types with the given labels to be read, after validating them.
+// It is meant to be called before the first read, it is not safe to call concurrently with Read.
+func (s *NeoService) RegisterLabels(labels ...string) error {
+	for _, label := range labels {
+		if err := ValidateIdentifier(label); err != nil {
+			return err
+		}
+	}
+	if s.labels == nil {
+		s.labels = map[string]bool{}
+	}
+	for _, label := range labels {
+		s.labels[label] = true
+	}
+	return nil
+}
+
+// checkQuery checks that the label of the concept type is registered and the predicates and fields of its query are valid
+func (s *NeoService) checkQuery(conceptType string, q ConceptTypeQuery) error {
+	if err := s.checkLabel(conceptType); err != nil {
+		return err
+	}
+	for _, predicate := range q.Predicates {
+		if err := ValidateIdentifier(predicate); err != nil {
+			return err
+		}
+	}
+	for field := range q.Fields {
+		if !IsMappableField(field) {
+			return fmt.Errorf("unsupported field %q", field)
+		}
+	}
+	return nil
+}
+
+// checkLabel checks that the label of the concept type is registered
+func (s *NeoService) checkLabel(conceptType string) error {
+	if !s.labels[conceptType] {
+		return fmt.Errorf("%w: %q", ErrLabelNotAllowed, conceptType)
+	}
+	return nil
+}
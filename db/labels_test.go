@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateIdentifier(t *testing.T) {
+	for _, valid := range []string{"Brand", "SpecialReport", "IS_CLASSIFIED_BY", "Genre2"} {
+		assert.NoError(t, ValidateIdentifier(valid), valid)
+	}
+	for _, invalid := range []string{"", "Invalid Concept", "Brand`) DETACH DELETE (x", "2Genre", "_Brand", "Brand:Person", strings.Repeat("A", 65)} {
+		assert.Error(t, ValidateIdentifier(invalid), invalid)
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert.Equal(t, "`Brand`", quoteIdentifier("Brand"))
+	assert.Equal(t, "`Bra``nd`", quoteIdentifier("Bra`nd"))
+	assert.Equal(t, "`ABOUT`|`MENTIONS`", quoteIdentifiers([]string{"ABOUT", "MENTIONS"}))
+}
+
+func TestNeoService_RegisterLabels(t *testing.T) {
+	s := NewNeoService(nil, "not-needed")
+	assert.Error(t, s.RegisterLabels("Genre", "Invalid Concept"))
+	assert.False(t, s.labels["Genre"], "no label should be registered if any is invalid")
+
+	require.NoError(t, s.RegisterLabels("Genre"))
+	assert.True(t, s.labels["Genre"])
+	assert.True(t, s.labels["Brand"], "the default labels should stay registered")
+}
+
+func TestNeoService_ReadRejectsUnregisteredLabels(t *testing.T) {
+	s := NewNeoService(nil, "not-needed")
+	s.Queries = map[string]ConceptTypeQuery{"Topic": {Predicates: []string{"ABOUT]-() DETACH DELETE (x"}}}
+
+	for _, conceptType := range []string{"Genre", "Brand`) DETACH DELETE (x", "Topic"} {
+		conceptCh := make(chan Concept)
+		count, found, err := s.Read(context.Background(), conceptType, ReadOptions{}, conceptCh)
+
+		assert.Error(t, err, conceptType)
+		assert.False(t, found)
+		assert.Equal(t, 0, count)
+		_, open := <-conceptCh
+		assert.False(t, open, "the channel should be closed")
+	}
+	_, _, err := s.Read(context.Background(), "Genre", ReadOptions{}, make(chan Concept))
+	assert.ErrorIs(t, err, ErrLabelNotAllowed)
+}
+
+func TestGetReadStatement_QuotesIdentifiers(t *testing.T) {
+	stmt := getSelectionStatement("Brand", DefaultQuery, ReadOptions{})
+	assert.Contains(t, stmt, "MATCH (x:`Brand`)\n")
+	assert.Contains(t, stmt, "OPTIONAL MATCH (x)<-[:EQUIVALENT_TO]-(:Concept)<-[rel:`MENTIONS`|")
+	assert.Contains(t, getReadStatement("Brand", DefaultQuery, ReadOptions{}), "MATCH (x:`Brand`)\n")
+	assert.Contains(t, stmt, "|`HAS_BRAND`]-(:Content)")
+}
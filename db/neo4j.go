@@ -1,28 +1,74 @@
 package db
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sort"
+	"strings"
+	"time"
 
 	cmneo4j "github.com/Financial-Times/cm-neo4j-driver"
 	"github.com/Financial-Times/neo-model-utils-go/mapper"
 )
 
-//Service reads from a data source and uses a channel to iterate on the retrieved values for the given concept type
+//Service reads from a data source and uses a channel to iterate on the retrieved values for the given concept type.
+//Read blocks until every value has been sent on the channel and returns the final count.
+//It stops early with the context's error when the context is cancelled.
 type Service interface {
-	Read(conceptType string, conceptCh chan Concept) (int, bool, error)
+	Read(ctx context.Context, conceptType string, opts ReadOptions, conceptCh chan Concept) (int, bool, error)
 }
 
+//ReadOptions narrows down the concepts returned by Read
+type ReadOptions struct {
+	// Since turns the read into a delta: only concepts whose canonical node or annotations changed after it are returned,
+	// each of them with its Change set
+	Since *time.Time
+	// Baseline has, by concept type, the sorted prefUUIDs of the concepts of the latest export a delta read takes the changes since.
+	// The concepts of the baseline which are not selected anymore are read as removed, and the changed concepts missing from it as added.
+	// Without a baseline for its concept type, a delta read has no removed concepts.
+	Baseline map[string][]string
+	// AnnotationStats adds the annotation usage statistics to every concept, see Concept.Annotations
+	AnnotationStats bool
+	// MinAnnotations keeps only the concepts with at least this number of annotations
+	MinAnnotations int
+	// AnnotatedSince keeps only the concepts annotated at or after the given time
+	AnnotatedSince *time.Time
+	// ExcludeDeprecated leaves out the deprecated canonical concepts
+	ExcludeDeprecated bool
+	// IncludeUnannotated reads every canonical concept of the type, with Concept.Annotated set.
+	// Delta reads, MinAnnotations above 1 and AnnotatedSince still keep only annotated concepts.
+	IncludeUnannotated bool
+}
+
+// Changes of a concept in a delta read
+const (
+	Added   = "added"
+	Changed = "changed"
+	Removed = "removed"
+)
+
 //NeoService is the implementation of Service for Neo4j
 type NeoService struct {
-	Driver *cmneo4j.Driver
-	NeoURL string
+	Driver   *cmneo4j.Driver
+	NeoURL   string
+	PageSize int
+	// Queries overrides the built-in queries of the concept types
+	Queries map[string]ConceptTypeQuery
+	// labels are the labels of the concept types which can be read, see RegisterLabels
+	labels map[string]bool
 }
 
+// defaultPageSize is the number of concepts read from Neo4j with a single query
+const defaultPageSize = 10000
+
 //Returns a new NeoService
 func NewNeoService(driver *cmneo4j.Driver, neoURL string) *NeoService {
-	return &NeoService{Driver: driver, NeoURL: neoURL}
+	s := &NeoService{Driver: driver, NeoURL: neoURL, PageSize: defaultPageSize}
+	// the default labels are valid
+	_ = s.RegisterLabels(DefaultLabels...)
+	return s
 }
 
 //Concept is the model for the data read from the data source
@@ -36,13 +82,39 @@ type Concept struct {
 	FactsetIDs                   []string
 	FigiCodes                    []string
 	NAICSIndustryClassifications []NAICSIndustryClassification
-	AlternativeLabels            []string
+	// ISO31661 is the ISO 3166-1 code of a location
+	ISO31661 string
+	// BroaderUUIDs are the locations a location is part of, the nearest first, e.g. the country then the region of a city
+	BroaderUUIDs []string
+	// NarrowerUUIDs are the locations directly part of a location
+	NarrowerUUIDs     []string
+	AlternativeLabels []string
 	// AlternativeLabels contains the values of:
 	Aliases     []string
 	FormerNames []string
 	ProperName  string
 	ShortName   string
 	TradeNames  []string
+	// Change is set only by delta reads
+	Change string
+	// Annotations is set only by reads with ReadOptions.AnnotationStats
+	Annotations *AnnotationStats
+	// Annotated is set only by reads with ReadOptions.IncludeUnannotated
+	Annotated *bool
+}
+
+// AnnotationStats tells how much a concept is used to annotate content, counting the annotations of all its source concepts
+type AnnotationStats struct {
+	// Mentions counts MENTIONS and MAJOR_MENTIONS
+	Mentions int `json:"mentions"`
+	About    int `json:"about"`
+	// IsClassifiedBy counts IS_CLASSIFIED_BY and IS_PRIMARILY_CLASSIFIED_BY
+	IsClassifiedBy int `json:"isClassifiedBy"`
+	HasAuthor      int `json:"hasAuthor"`
+	HasBrand       int `json:"hasBrand"`
+	// FirstAnnotated and LastAnnotated are the epoch seconds of the oldest and newest annotations, 0 if unknown
+	FirstAnnotated int64 `json:"firstAnnotated"`
+	LastAnnotated  int64 `json:"lastAnnotated"`
 }
 
 type NAICSIndustryClassification struct {
@@ -50,67 +122,474 @@ type NAICSIndustryClassification struct {
 	Rank               int    `json:"rank,omitempty"`
 }
 
-func (s *NeoService) Read(conceptType string, conceptCh chan Concept) (int, bool, error) {
-	results := []Concept{}
-	stmt := fmt.Sprintf(`
-		MATCH (x:%s)<-[:EQUIVALENT_TO]-(:Concept)<-[:MENTIONS|MAJOR_MENTIONS|ABOUT|IS_CLASSIFIED_BY|IS_PRIMARILY_CLASSIFIED_BY|HAS_AUTHOR|HAS_BRAND]-(:Content)
-		USING SCAN x:%[1]s
-		RETURN DISTINCT x.prefUUID AS Uuid, x.prefLabel AS PrefLabel, labels(x) AS Labels,
-			x.aliases as Aliases, x.formerNames as FormerNames,	x.properName as ProperName, x.shortName as ShortName
-		`, conceptType)
-
-	if conceptType == "Organisation" {
-		stmt = `
-		MATCH (:Content)-[:MENTIONS|MAJOR_MENTIONS|ABOUT|IS_CLASSIFIED_BY|IS_PRIMARILY_CLASSIFIED_BY|HAS_AUTHOR]->()-[:EQUIVALENT_TO]->(x:Organisation)
-		USING SCAN x:Organisation
-		WITH DISTINCT x
-		MATCH (x)<-[:EQUIVALENT_TO]-(concept)
+func (s *NeoService) Read(ctx context.Context, conceptType string, opts ReadOptions, conceptCh chan Concept) (int, bool, error) {
+	defer close(conceptCh)
+
+	q := s.getQuery(conceptType)
+	if err := s.checkQuery(conceptType, q); err != nil {
+		return 0, false, err
+	}
+	baseline, hasBaseline := opts.Baseline[conceptType]
+	count, err := readPages(ctx, s, getSelectionStatement(conceptType, q, opts), getReadStatement(conceptType, q, opts), getReadParams(opts), func(c Concept) error {
+		if opts.Since != nil && hasBaseline {
+			c.Change = Changed
+			if !containsUUID(baseline, c.UUID) {
+				c.Change = Added
+			}
+		}
+		return sendConcept(ctx, c, conceptCh)
+	})
+	if err != nil || opts.Since == nil || !hasBaseline {
+		return count, count > 0, err
+	}
+	n, err := s.readRemoved(ctx, conceptType, q, opts, baseline, conceptCh)
+	count += n
+	return count, count > 0, err
+}
+
+// readRemoved reads the concepts of the baseline which are not selected anymore as removed.
+// Those deleted from Neo4j, or left out by the match of the query, are sent with their UUID and ID only.
+func (s *NeoService) readRemoved(ctx context.Context, conceptType string, q ConceptTypeQuery, opts ReadOptions, baseline []string, conceptCh chan Concept) (int, error) {
+	current := opts
+	current.Since = nil
+	var selected []string
+	_, err := selectPages(ctx, s, getSelectionStatement(conceptType, q, current), getReadParams(current), func(page []selectedRow) (int, error) {
+		for _, row := range page {
+			selected = append(selected, row.UUID)
+		}
+		return len(page), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	sort.Strings(selected)
+	var removed []string
+	for _, uuid := range baseline {
+		if !containsUUID(selected, uuid) {
+			removed = append(removed, uuid)
+		}
+	}
+
+	count := 0
+	pageSize := s.pageSize()
+	for start := 0; start < len(removed); start += pageSize {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+		uuids := removed[start:min(start+pageSize, len(removed))]
+		changes := make(map[string]interface{}, len(uuids))
+		for _, uuid := range uuids {
+			changes[uuid] = Removed
+		}
+		read := map[string]bool{}
+		n, err := project(s, getReadStatement(conceptType, q, current), uuids, changes, getReadParams(current), func(c Concept) error {
+			read[c.UUID] = true
+			return sendConcept(ctx, c, conceptCh)
+		})
+		count += n
+		if err != nil {
+			return count, err
+		}
+		for _, uuid := range uuids {
+			if read[uuid] {
+				continue
+			}
+			if err := sendConcept(ctx, Concept{UUID: uuid, Change: Removed}, conceptCh); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// containsUUID tells whether the sorted UUIDs contain the given one
+func containsUUID(sorted []string, uuid string) bool {
+	i := sort.SearchStrings(sorted, uuid)
+	return i < len(sorted) && sorted[i] == uuid
+}
+
+// sendConcept completes the fields of a concept derived from the ones read and sends it on the channel
+func sendConcept(ctx context.Context, c Concept, conceptCh chan Concept) error {
+	c.APIURL = mapper.APIURL(c.UUID, c.Labels, "")
+	c.ID = mapper.IDURL(c.UUID)
+	c.NAICSIndustryClassifications = cleanNAICS(c.NAICSIndustryClassifications)
+	c.AlternativeLabels = ConsolidateAlternativeLabels(c.Aliases, c.FormerNames, c.ProperName, c.ShortName, c.TradeNames)
+	c.BroaderUUIDs = otherUUIDs(c.BroaderUUIDs, c.UUID)
+	c.NarrowerUUIDs = otherUUIDs(c.NarrowerUUIDs, c.UUID)
+	select {
+	case conceptCh <- c:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// selectedRow is a canonical node selected for a page, see getSelectionStatement
+type selectedRow struct {
+	UUID   string `json:"Uuid"`
+	Change string `json:"Change"`
+}
+
+// selectedPage is the result of the selection statement for a page, see getSelection
+type selectedPage struct {
+	// Cursor is the highest prefUUID of the window of the page, the next page starts after it
+	Cursor string `json:"Cursor"`
+	// Scanned is the number of canonical nodes in the window, fewer than the page size for the last page
+	Scanned int           `json:"Scanned"`
+	Rows    []selectedRow `json:"Page"`
+}
+
+// readPages selects the canonical nodes page by page with the selection statement, then runs the projection statement
+// for the nodes of every page and hands every row it returns to handle.
+// Every page starts after the window of canonical nodes of the previous one. The pages are cut before the projection,
+// so a projection returning fewer rows than the nodes of its page, e.g. with the match of a configured query, doesn't end the read.
+func readPages[T any](ctx context.Context, s *NeoService, selection, projection string, params map[string]interface{}, handle func(T) error) (int, error) {
+	return selectPages(ctx, s, selection, params, func(page []selectedRow) (int, error) {
+		uuids := make([]string, 0, len(page))
+		changes := make(map[string]interface{}, len(page))
+		for _, row := range page {
+			uuids = append(uuids, row.UUID)
+			if row.Change != "" {
+				changes[row.UUID] = row.Change
+			}
+		}
+		return project(s, projection, uuids, changes, params, handle)
+	})
+}
+
+// selectPages runs the selection statement page by page and hands every page of selected canonical nodes to handle,
+// sorted by prefUUID, which returns the number of rows it has read for them. A page selecting no node is skipped.
+func selectPages(ctx context.Context, s *NeoService, selection string, params map[string]interface{}, handle func([]selectedRow) (int, error)) (int, error) {
+	pageSize := s.pageSize()
+	count := 0
+	lastUUID := ""
+	for {
+		// the driver can't interrupt a running query, so cancellation is checked between pages
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+
+		pageParams := map[string]interface{}{
+			"lastUUID": lastUUID,
+			"pageSize": pageSize,
+		}
+		for k, v := range params {
+			pageParams[k] = v
+		}
+		var pages []selectedPage
+		err := s.Driver.Read(&cmneo4j.Query{
+			Cypher: selection,
+			Params: pageParams,
+			Result: &pages,
+		})
+		if errors.Is(err, cmneo4j.ErrNoResultsFound) {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+		if len(pages) == 0 || pages[0].Cursor == "" {
+			return count, nil
+		}
+
+		page := pages[0]
+		lastUUID = page.Cursor
+		if len(page.Rows) != 0 {
+			sort.Slice(page.Rows, func(i, j int) bool {
+				return page.Rows[i].UUID < page.Rows[j].UUID
+			})
+			n, err := handle(page.Rows)
+			count += n
+			if err != nil {
+				return count, err
+			}
+		}
+		if page.Scanned < pageSize {
+			return count, nil
+		}
+	}
+}
+
+// project runs the projection statement for the canonical nodes with the given prefUUIDs and Changes,
+// and hands every row it returns to handle
+func project[T any](s *NeoService, projection string, uuids []string, changes map[string]interface{}, params map[string]interface{}, handle func(T) error) (int, error) {
+	projectionParams := map[string]interface{}{
+		"uuids":   uuids,
+		"changes": changes,
+	}
+	for k, v := range params {
+		projectionParams[k] = v
+	}
+	var rows []T
+	err := s.Driver.Read(&cmneo4j.Query{
+		Cypher: projection,
+		Params: projectionParams,
+		Result: &rows,
+	})
+	if err != nil && !errors.Is(err, cmneo4j.ErrNoResultsFound) {
+		return 0, err
+	}
+	for i, row := range rows {
+		if err := handle(row); err != nil {
+			return i, err
+		}
+	}
+	return len(rows), nil
+}
+
+func (s *NeoService) pageSize() int {
+	if s.PageSize <= 0 {
+		return defaultPageSize
+	}
+	return s.PageSize
+}
+
+// AnnotatingPredicates are the relationships between content and the concepts it is annotated with
+var AnnotatingPredicates = []string{"MENTIONS", "MAJOR_MENTIONS", "ABOUT", "IS_CLASSIFIED_BY", "IS_PRIMARILY_CLASSIFIED_BY", "HAS_AUTHOR"}
+
+// BrandPredicate relates content to its brands, which counts as an annotation for the concept types read with DefaultQuery
+const BrandPredicate = "HAS_BRAND"
+
+// ConceptTypeQuery defines how the concepts of a type are read from Neo4j.
+// Every query returns the UUID, prefLabel and labels of the canonical node x, the other fields come from Fields.
+type ConceptTypeQuery struct {
+	// Predicates are the relationships from content counting as annotations, those of DefaultQuery if empty
+	Predicates []string `json:"predicates,omitempty" yaml:"predicates,omitempty"`
+	// Match is Cypher run for every selected canonical node x before its fields are returned, e.g. to match related nodes.
+	// It has to keep x and Change in scope.
+	Match string `json:"match,omitempty" yaml:"match,omitempty"`
+	// Fields maps the fields of Concept to the Cypher expressions they are read from, those of DefaultQuery if empty
+	Fields map[string]string `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// alternativeLabelFields are read for every concept type by default
+var alternativeLabelFields = map[string]string{
+	"Aliases":     "x.aliases",
+	"FormerNames": "x.formerNames",
+	"ProperName":  "x.properName",
+	"ShortName":   "x.shortName",
+}
+
+// DefaultQuery reads the concept types without a query of their own
+var DefaultQuery = ConceptTypeQuery{
+	Predicates: append(append([]string{}, AnnotatingPredicates...), BrandPredicate),
+	Fields:     alternativeLabelFields,
+}
+
+// DefaultQueries are the built-in queries of the concept types which aren't read with DefaultQuery.
+// People and organisations are not exported for being the brand of content.
+var DefaultQueries = map[string]ConceptTypeQuery{
+	"Person": {Predicates: AnnotatingPredicates, Fields: alternativeLabelFields},
+	"Organisation": {
+		Predicates: AnnotatingPredicates,
+		Match: `MATCH (x)<-[:EQUIVALENT_TO]-(concept)
 		OPTIONAL MATCH (concept)<-[:ISSUED_BY]-(fi:FinancialInstrument)
 		OPTIONAL MATCH (concept)-[hasICRel:HAS_INDUSTRY_CLASSIFICATION]->(:NAICSIndustryClassification)-[:EQUIVALENT_TO]->(naicsCanonical:NAICSIndustryClassification)
-		WITH x, collect(DISTINCT CASE concept.authority WHEN 'FACTSET' THEN concept.authorityValue END) AS factsetIds,
-			collect(DISTINCT fi.figiCode) as figiCodes, collect(DISTINCT {id: naicsCanonical.industryIdentifier, rank: hasICRel.rank}) as naicsIndustryClassifications 
-		RETURN x.prefUUID AS Uuid, labels(x) AS Labels, x.prefLabel AS PrefLabel, x.leiCode AS leiCode,
-			x.aliases as Aliases, x.formerNames as FormerNames,	x.properName as ProperName, x.shortName as ShortName,
-			factsetIds,
-			figiCodes,
-			naicsIndustryClassifications
+		WITH x, Change, collect(DISTINCT CASE concept.authority WHEN 'FACTSET' THEN concept.authorityValue END) AS factsetIds,
+			collect(DISTINCT fi.figiCode) as figiCodes, collect(DISTINCT {id: naicsCanonical.industryIdentifier, rank: hasICRel.rank}) as naicsIndustryClassifications`,
+		Fields: map[string]string{
+			"LeiCode":                      "x.leiCode",
+			"Aliases":                      "x.aliases",
+			"FormerNames":                  "x.formerNames",
+			"ProperName":                   "x.properName",
+			"ShortName":                    "x.shortName",
+			"FactsetIDs":                   "factsetIds",
+			"FigiCodes":                    "figiCodes",
+			"NAICSIndustryClassifications": "naicsIndustryClassifications",
+		},
+	},
+	// Location reads the ISO 3166-1 code and the broader locations up to three levels above, e.g. city, country and region
+	"Location": {
+		Match: `MATCH (x)<-[:EQUIVALENT_TO]-(concept)
+		OPTIONAL MATCH (concept)-[:HAS_BROADER]->()-[:EQUIVALENT_TO]->(broader1:Location)
+		OPTIONAL MATCH (broader1)<-[:EQUIVALENT_TO]-()-[:HAS_BROADER]->()-[:EQUIVALENT_TO]->(broader2:Location)
+		OPTIONAL MATCH (broader2)<-[:EQUIVALENT_TO]-()-[:HAS_BROADER]->()-[:EQUIVALENT_TO]->(broader3:Location)
+		WITH x, Change, collect(DISTINCT broader1.prefUUID) + collect(DISTINCT broader2.prefUUID) + collect(DISTINCT broader3.prefUUID) AS broaderUUIDs`,
+		Fields: map[string]string{
+			"Aliases":       "x.aliases",
+			"FormerNames":   "x.formerNames",
+			"ProperName":    "x.properName",
+			"ShortName":     "x.shortName",
+			"ISO31661":      "x.iso31661",
+			"BroaderUUIDs":  "broaderUUIDs",
+			"NarrowerUUIDs": "[(x)<-[:EQUIVALENT_TO]-(:Concept)<-[:HAS_BROADER]-()-[:EQUIVALENT_TO]->(narrower:Location) | narrower.prefUUID]",
+		},
+	},
+}
+
+// mappableFields are the fields of Concept a ConceptTypeQuery can read
+var mappableFields = map[string]bool{
+	"LeiCode":                      true,
+	"FactsetIDs":                   true,
+	"FigiCodes":                    true,
+	"NAICSIndustryClassifications": true,
+	"Aliases":                      true,
+	"FormerNames":                  true,
+	"ProperName":                   true,
+	"ShortName":                    true,
+	"TradeNames":                   true,
+	"ISO31661":                     true,
+	"BroaderUUIDs":                 true,
+	"NarrowerUUIDs":                true,
+}
+
+// IsMappableField tells whether a ConceptTypeQuery can read the given field of Concept
+func IsMappableField(field string) bool {
+	return mappableFields[field]
+}
+
+func (s *NeoService) getQuery(conceptType string) ConceptTypeQuery {
+	q, found := s.Queries[conceptType]
+	if !found {
+		q, found = DefaultQueries[conceptType]
+	}
+	if !found {
+		q = DefaultQuery
+	}
+	if len(q.Predicates) == 0 {
+		q.Predicates = DefaultQuery.Predicates
+	}
+	if len(q.Fields) == 0 {
+		q.Fields = DefaultQuery.Fields
+	}
+	return q
+}
+
+// getReadParams returns the parameters of the statements of a read with the given options
+func getReadParams(opts ReadOptions) map[string]interface{} {
+	params := map[string]interface{}{}
+	if opts.Since != nil {
+		params["since"] = opts.Since.Unix()
+	}
+	if opts.MinAnnotations > 1 {
+		params["minAnnotations"] = opts.MinAnnotations
+	}
+	if opts.AnnotatedSince != nil {
+		params["annotatedSince"] = opts.AnnotatedSince.Unix()
+	}
+	return params
+}
+
+// getSelectionStatement returns the Cypher selecting one page of canonical concepts of the given type,
+// annotated unless the options include unannotated ones, as their Uuid and Change
+func getSelectionStatement(conceptType string, q ConceptTypeQuery, opts ReadOptions) string {
+	return getSelection(conceptType, q, opts) + selectionReturn
+}
+
+// selectionReturn returns the canonical nodes selected for a page with their Change, and the window of the page
+const selectionReturn = `RETURN Cursor, Scanned, Page
 		`
+
+// getReadStatement returns the Cypher reading the concepts of the given type selected for a page.
+// The label and the predicates are quoted, the match and the fields of the query are trusted Cypher.
+func getReadStatement(conceptType string, q ConceptTypeQuery, opts ReadOptions) string {
+	return getPageMatch(conceptType) + getProjection(q, opts)
+}
+
+// getPageMatch returns the Cypher matching the canonical nodes x of the given type selected for a page, with their Change.
+// Their prefUUIDs are the $uuids parameter, and $changes maps them to their Change.
+func getPageMatch(conceptType string) string {
+	return fmt.Sprintf(`
+		MATCH (x:%s)
+		WHERE x.prefUUID IN $uuids
+		WITH x, $changes[x.prefUUID] AS Change
+		`, quoteIdentifier(conceptType))
+}
+
+// getSelection returns the Cypher selecting one page of annotated canonical nodes x of the given type with their Change,
+// or of all of them if the options include unannotated concepts without filtering them by their annotations.
+// Every page seeks the window of the next $pageSize canonical nodes after $lastUUID in prefUUID order,
+// served by an index on the prefUUID of the label, before matching and counting the annotations of the nodes of the window only.
+// The nodes of the window are filtered by the options, so a page can select fewer nodes than its window holds.
+// For a delta read it keeps only the concepts changed or annotated since the given time.
+// Annotation times come from annotatedDateEpoch, canonical node changes from lastModifiedEpoch.
+func getSelection(conceptType string, q ConceptTypeQuery, opts ReadOptions) string {
+	filters := []string{"annotations > 0"}
+	if opts.MinAnnotations > 1 {
+		filters = append(filters, "annotations >= $minAnnotations")
+	}
+	if opts.AnnotatedSince != nil {
+		filters = append(filters, "lastAnnotated >= $annotatedSince")
 	}
-	if conceptType == "Person" {
-		stmt = `
-		MATCH (:Content)-[:MENTIONS|MAJOR_MENTIONS|ABOUT|IS_CLASSIFIED_BY|IS_PRIMARILY_CLASSIFIED_BY|HAS_AUTHOR]->(:Concept)-[:EQUIVALENT_TO]->(x:Person)
-		USING SCAN x:Person
-		RETURN DISTINCT x.prefUUID as Uuid, x.prefLabel as PrefLabel, labels(x) as Labels,
-			x.aliases as Aliases, x.formerNames as FormerNames,	x.properName as ProperName, x.shortName as ShortName
+	change := "null"
+	if opts.Since != nil {
+		filters = append(filters, "(x.lastModifiedEpoch > $since OR lastAnnotated > $since)")
+		change = "CASE WHEN firstAnnotated > $since THEN 'added' ELSE 'changed' END"
+	}
+	window := fmt.Sprintf(`
+		MATCH (x:%s)
+		WHERE %s
+		WITH x
+		ORDER BY x.prefUUID
+		LIMIT $pageSize
+		`, quoteIdentifier(conceptType), getNodeConditions(opts))
+	if opts.IncludeUnannotated && len(filters) == 1 {
+		return window + `WITH max(x.prefUUID) AS Cursor, count(x) AS Scanned, collect({Uuid: x.prefUUID, Change: null}) AS Page
 		`
 	}
+	return window + fmt.Sprintf(`OPTIONAL MATCH (x)<-[:EQUIVALENT_TO]-(:Concept)<-[rel:%s]-(:Content)
+		WITH x, count(rel) AS annotations, min(rel.annotatedDateEpoch) AS firstAnnotated, max(rel.annotatedDateEpoch) AS lastAnnotated
+		WITH max(x.prefUUID) AS Cursor, count(x) AS Scanned,
+			collect(CASE WHEN %s THEN {Uuid: x.prefUUID, Change: %s} END) AS Page
+		`, quoteIdentifiers(q.Predicates), strings.Join(filters, " AND "), change)
+}
 
-	query := &cmneo4j.Query{
-		Cypher: stmt,
-		Result: &results,
+// getNodeConditions returns the conditions on the canonical nodes x of a page
+func getNodeConditions(opts ReadOptions) string {
+	conditions := "x.prefUUID > $lastUUID"
+	if opts.ExcludeDeprecated {
+		conditions += " AND NOT coalesce(x.isDeprecated, false)"
 	}
+	return conditions
+}
 
-	err := s.Driver.Read(query)
+// getProjection returns the Cypher turning the selected canonical nodes x into the returned fields,
+// each of them returned under the name of its Concept field
+func getProjection(q ConceptTypeQuery, opts ReadOptions) string {
+	fields := make([]string, 0, len(q.Fields))
+	for field := range q.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
 
-	if errors.Is(err, cmneo4j.ErrNoResultsFound) {
-		close(conceptCh)
-		return 0, false, nil
+	returned := []string{"x.prefUUID AS Uuid", "x.prefLabel AS PrefLabel", "labels(x) AS Labels"}
+	for _, field := range fields {
+		returned = append(returned, fmt.Sprintf("%s AS %s", q.Fields[field], field))
 	}
-	if err != nil {
-		close(conceptCh)
-		return 0, false, err
+	if opts.AnnotationStats {
+		returned = append(returned, fmt.Sprintf(annotationStatsExpression, quoteIdentifiers(q.Predicates))+" AS Annotations")
+	}
+	if opts.IncludeUnannotated {
+		returned = append(returned, fmt.Sprintf("exists((x)<-[:EQUIVALENT_TO]-(:Concept)<-[:%s]-(:Content)) AS Annotated", quoteIdentifiers(q.Predicates)))
 	}
-	go func() {
-		defer close(conceptCh)
-		for _, c := range results {
-			c.APIURL = mapper.APIURL(c.UUID, c.Labels, "")
-			c.ID = mapper.IDURL(c.UUID)
-			c.NAICSIndustryClassifications = cleanNAICS(c.NAICSIndustryClassifications)
-			c.AlternativeLabels = ConsolidateAlternativeLabels(c.Aliases, c.FormerNames, c.ProperName, c.ShortName, c.TradeNames)
-			conceptCh <- c
-		}
-	}()
-	return len(results), true, nil
+	returned = append(returned, "Change")
+	return fmt.Sprintf(`%s
+		RETURN %s
+		ORDER BY Uuid`, q.Match, strings.Join(returned, ", "))
+}
+
+// annotationStatsExpression is the Cypher of the AnnotationStats of the canonical node x.
+// The annotations with the predicates of the query are matched once, then counted and dated from the list they are collected in.
+const annotationStatsExpression = `[annotations IN [[(x)<-[:EQUIVALENT_TO]-(:Concept)<-[rel:%s]-(:Content) | {predicate: type(rel), annotated: rel.annotatedDateEpoch}]] | {
+			mentions: size([a IN annotations WHERE a.predicate IN ['MENTIONS', 'MAJOR_MENTIONS']]),
+			about: size([a IN annotations WHERE a.predicate = 'ABOUT']),
+			isClassifiedBy: size([a IN annotations WHERE a.predicate IN ['IS_CLASSIFIED_BY', 'IS_PRIMARILY_CLASSIFIED_BY']]),
+			hasAuthor: size([a IN annotations WHERE a.predicate = 'HAS_AUTHOR']),
+			hasBrand: size([a IN annotations WHERE a.predicate = 'HAS_BRAND']),
+			firstAnnotated: reduce(first = null, a IN annotations | CASE WHEN first IS NULL OR a.annotated < first THEN a.annotated ELSE first END),
+			lastAnnotated: reduce(last = null, a IN annotations | CASE WHEN last IS NULL OR a.annotated > last THEN a.annotated ELSE last END)
+		}][0]`
+
+// otherUUIDs returns the UUIDs without duplicates and the UUID of the concept itself, in their order
+func otherUUIDs(uuids []string, self string) []string {
+	var res []string
+	for _, uuid := range uuids {
+		if uuid != self && !slices.Contains(res, uuid) {
+			res = append(res, uuid)
+		}
+	}
+	return res
 }
 
 func ConsolidateAlternativeLabels(aliases []string, formerNames []string, properName, shortName string, tradeNames []string) []string {
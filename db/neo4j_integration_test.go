@@ -4,6 +4,7 @@
 package db
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -34,9 +35,16 @@ const (
 	personWithBrandUUID         = "9070a3f1-aa6d-48a7-9d97-f56a47513cef"
 	industryClassificationUUID  = "49da878c-67ce-4343-9a09-a4a767e584a2"
 	industryClassificationUUID2 = "38ee195d-ebdd-48a9-af4b-c8a322e7b04d"
+	regionUUID                  = "6f8a3c1e-2b4d-4e5f-9a7b-1c2d3e4f5a6b"
+	countryUUID                 = "8b2c4d6e-3f5a-4b7c-8d9e-2f3a4b5c6d7e"
+	cityUUID                    = "9c3d5e7f-4a6b-4c8d-9e0f-3a4b5c6d7e8f"
+	membershipUUID              = "4e6f8a0b-5c7d-4e9f-8a1b-2c3d4e5f6a7b"
 )
 
-var allUUIDs = []string{contentUUID, brandParentUUID, brandChildUUID, brandGrandChildUUID, financialInstrumentUUID, companyUUID, organisationUUID, personUUID, personWithBrandUUID, industryClassificationUUID, industryClassificationUUID2, "eac853f5-3859-4c08-8540-55e043719401", "eac853f5-3859-4c08-8540-55e043719402", "dbb0bdae-1f0c-11e4-b0cb-b2227cce2b54", "a7b4786c-aae9-3e3e-93a0-2c82a6383534", "22a60434-a9d5-3a38-a337-fdd904e99f6f"}
+// readBufferSize lets Read, which blocks until every concept is sent, return before the test drains the channel
+const readBufferSize = 10
+
+var allUUIDs = []string{contentUUID, brandParentUUID, brandChildUUID, brandGrandChildUUID, financialInstrumentUUID, companyUUID, organisationUUID, personUUID, personWithBrandUUID, industryClassificationUUID, industryClassificationUUID2, regionUUID, countryUUID, cityUUID, membershipUUID, "eac853f5-3859-4c08-8540-55e043719401", "eac853f5-3859-4c08-8540-55e043719402", "dbb0bdae-1f0c-11e4-b0cb-b2227cce2b54", "a7b4786c-aae9-3e3e-93a0-2c82a6383534", "22a60434-a9d5-3a38-a337-fdd904e99f6f"}
 
 func getNeo4jDriver(t *testing.T) *cmneo4j.Driver {
 	url := os.Getenv("NEO4J_TEST_URL")
@@ -66,8 +74,8 @@ func TestNeoService_ReadBrand(t *testing.T) {
 
 	neoSvc := NewNeoService(driver, "not-needed")
 
-	conceptCh := make(chan Concept)
-	count, found, err := neoSvc.Read("Brand", conceptCh)
+	conceptCh := make(chan Concept, readBufferSize)
+	count, found, err := neoSvc.Read(context.Background(), "Brand", ReadOptions{}, conceptCh)
 
 	assert.NoError(t, err, "Error reading from Neo")
 	assert.True(t, found)
@@ -92,6 +100,140 @@ waitLoop:
 	}
 }
 
+func TestNeoService_ReadRelationships(t *testing.T) {
+	driver := getNeo4jDriver(t)
+
+	log := logger.NewUPPLogger("concept-exporter-test", "PANIC")
+	svc := concepts.NewConceptService(driver, log)
+	assert.NoError(t, svc.Initialise())
+
+	cleanDB(t, driver)
+	writeBrands(t, &svc)
+	writeContent(t, driver)
+	writeAnnotation(t, driver, fmt.Sprintf("./fixtures/Annotations-%s.json", contentUUID), "v1")
+
+	neoSvc := NewNeoService(driver, "not-needed")
+
+	relCh := make(chan Relationship, readBufferSize)
+	count, err := neoSvc.ReadRelationships(context.Background(), "Brand", ReadOptions{}, relCh)
+
+	assert.NoError(t, err, "Error reading from Neo")
+	assert.Equal(t, 1, count)
+	var relationships []Relationship
+	for rel := range relCh {
+		relationships = append(relationships, rel)
+	}
+	// only the annotated child brand is exported, with the parent it has through its source concept
+	assert.Equal(t, []Relationship{{
+		SourceID:  "http://api.ft.com/things/" + brandChildUUID,
+		Predicate: "HAS_PARENT",
+		TargetID:  "http://api.ft.com/things/" + brandParentUUID,
+	}}, relationships)
+}
+
+func TestNeoService_ReadMembershipRelationships(t *testing.T) {
+	driver := getNeo4jDriver(t)
+
+	log := logger.NewUPPLogger("concept-exporter-test", "PANIC")
+	svc := concepts.NewConceptService(driver, log)
+	assert.NoError(t, svc.Initialise())
+
+	cleanDB(t, driver)
+	writeJSONToConceptService(t, &svc, fmt.Sprintf("./fixtures/Person-%s.json", personUUID))
+	writeJSONToConceptService(t, &svc, fmt.Sprintf("./fixtures/Organisation-Fakebook-%s.json", companyUUID))
+	writeJSONToConceptService(t, &svc, fmt.Sprintf("./fixtures/Membership-%s.json", membershipUUID))
+	writeContent(t, driver)
+	writeAnnotation(t, driver, fmt.Sprintf("./fixtures/Annotations-%s-person.json", contentUUID), "pac")
+
+	neoSvc := NewNeoService(driver, "not-needed")
+
+	relCh := make(chan Relationship, readBufferSize)
+	count, err := neoSvc.ReadRelationships(context.Background(), "Person", ReadOptions{}, relCh)
+
+	assert.NoError(t, err, "Error reading from Neo")
+	assert.Equal(t, 1, count)
+	var relationships []Relationship
+	for rel := range relCh {
+		relationships = append(relationships, rel)
+	}
+	// the membership between the person and the organisation is read through the Membership node
+	assert.Equal(t, []Relationship{{
+		SourceID:  "http://api.ft.com/things/" + personUUID,
+		Predicate: MembershipPredicate,
+		TargetID:  "http://api.ft.com/things/" + companyUUID,
+	}}, relationships)
+}
+
+func TestNeoService_ReadInPages(t *testing.T) {
+	driver := getNeo4jDriver(t)
+
+	log := logger.NewUPPLogger("concept-exporter-test", "PANIC")
+	svc := concepts.NewConceptService(driver, log)
+	assert.NoError(t, svc.Initialise())
+
+	cleanDB(t, driver)
+	writeBrands(t, &svc)
+	writeContent(t, driver)
+	writeAnnotation(t, driver, fmt.Sprintf("./fixtures/Annotations-%s.json", contentUUID), "v1")
+
+	neoSvc := NewNeoService(driver, "not-needed")
+	neoSvc.PageSize = 1
+
+	// the three brands are read, a single one of them being annotated
+	conceptCh := make(chan Concept, readBufferSize)
+	count, found, err := neoSvc.Read(context.Background(), "Brand", ReadOptions{IncludeUnannotated: true}, conceptCh)
+
+	assert.NoError(t, err, "Error reading from Neo")
+	assert.True(t, found)
+	assert.Equal(t, 3, count)
+
+	var uuids []string
+	for c := range conceptCh {
+		uuids = append(uuids, c.UUID)
+	}
+	assert.Equal(t, []string{brandParentUUID, brandChildUUID, brandGrandChildUUID}, uuids, "every brand should be read exactly once")
+
+	// a match dropping the concept of a page doesn't end the read
+	neoSvc.Queries = map[string]ConceptTypeQuery{"Brand": {Match: fmt.Sprintf("MATCH (x) WHERE x.prefUUID <> '%s'", brandParentUUID)}}
+	conceptCh = make(chan Concept, readBufferSize)
+	count, _, err = neoSvc.Read(context.Background(), "Brand", ReadOptions{IncludeUnannotated: true}, conceptCh)
+
+	assert.NoError(t, err, "Error reading from Neo")
+	assert.Equal(t, 2, count)
+	uuids = nil
+	for c := range conceptCh {
+		uuids = append(uuids, c.UUID)
+	}
+	assert.Equal(t, []string{brandChildUUID, brandGrandChildUUID}, uuids)
+}
+
+func TestNeoService_ReadCancelled(t *testing.T) {
+	driver := getNeo4jDriver(t)
+
+	log := logger.NewUPPLogger("concept-exporter-test", "PANIC")
+	svc := concepts.NewConceptService(driver, log)
+	assert.NoError(t, svc.Initialise())
+
+	cleanDB(t, driver)
+	writeBrands(t, &svc)
+	writeContent(t, driver)
+	writeAnnotation(t, driver, fmt.Sprintf("./fixtures/Annotations-%s.json", contentUUID), "v1")
+
+	neoSvc := NewNeoService(driver, "not-needed")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	conceptCh := make(chan Concept, readBufferSize)
+	count, found, err := neoSvc.Read(ctx, "Brand", ReadOptions{}, conceptCh)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, found)
+	assert.Equal(t, 0, count)
+	_, open := <-conceptCh
+	assert.False(t, open)
+}
+
 func TestNeoService_DoNotReadBrokenConcepts(t *testing.T) {
 	driver := getNeo4jDriver(t)
 
@@ -154,8 +296,8 @@ func TestNeoService_DoNotReadBrokenConcepts(t *testing.T) {
 
 			neoSvc := NewNeoService(driver, "not-needed")
 
-			conceptCh := make(chan Concept)
-			count, found, err := neoSvc.Read(test.conceptType, conceptCh)
+			conceptCh := make(chan Concept, readBufferSize)
+			count, found, err := neoSvc.Read(context.Background(), test.conceptType, ReadOptions{}, conceptCh)
 
 			assert.NoError(t, err, "Error reading from Neo")
 			assert.False(t, found)
@@ -178,8 +320,8 @@ func TestNeoService_ReadHasBrand(t *testing.T) {
 
 	neoSvc := NewNeoService(driver, "not-needed")
 
-	conceptCh := make(chan Concept)
-	count, found, err := neoSvc.Read("Brand", conceptCh)
+	conceptCh := make(chan Concept, readBufferSize)
+	count, found, err := neoSvc.Read(context.Background(), "Brand", ReadOptions{}, conceptCh)
 
 	assert.NoError(t, err, "Error reading from Neo")
 	assert.True(t, found)
@@ -204,6 +346,183 @@ waitLoop:
 	}
 }
 
+func TestNeoService_ReadAnnotationStats(t *testing.T) {
+	driver := getNeo4jDriver(t)
+
+	log := logger.NewUPPLogger("concept-exporter-test", "PANIC")
+	svc := concepts.NewConceptService(driver, log)
+	assert.NoError(t, svc.Initialise())
+
+	cleanDB(t, driver)
+	writeBrands(t, &svc)
+	writeContent(t, driver)
+	writeAnnotation(t, driver, fmt.Sprintf("./fixtures/Annotations-%s-hasBrand.json", contentUUID), "v1")
+
+	neoSvc := NewNeoService(driver, "not-needed")
+
+	conceptCh := make(chan Concept, readBufferSize)
+	count, _, err := neoSvc.Read(context.Background(), "Brand", ReadOptions{AnnotationStats: true}, conceptCh)
+
+	assert.NoError(t, err, "Error reading from Neo")
+	assert.Equal(t, 1, count)
+	c := <-conceptCh
+	// annotated at 2016-01-20T19:43:47Z
+	assert.Equal(t, &AnnotationStats{HasBrand: 1, FirstAnnotated: 1453319027, LastAnnotated: 1453319027}, c.Annotations)
+}
+
+func TestNeoService_ReadWithFilters(t *testing.T) {
+	driver := getNeo4jDriver(t)
+
+	log := logger.NewUPPLogger("concept-exporter-test", "PANIC")
+	svc := concepts.NewConceptService(driver, log)
+	assert.NoError(t, svc.Initialise())
+
+	cleanDB(t, driver)
+	writeBrands(t, &svc)
+	writeContent(t, driver)
+	writeAnnotation(t, driver, fmt.Sprintf("./fixtures/Annotations-%s-hasBrand.json", contentUUID), "v1")
+
+	neoSvc := NewNeoService(driver, "not-needed")
+	// the child brand has a single annotation, made at 2016-01-20T19:43:47Z
+	before := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	after := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		opts     ReadOptions
+		expected int
+	}{
+		"enough annotations":     {opts: ReadOptions{MinAnnotations: 1, ExcludeDeprecated: true}, expected: 1},
+		"not enough annotations": {opts: ReadOptions{MinAnnotations: 2}, expected: 0},
+		"annotated since":        {opts: ReadOptions{AnnotatedSince: &before}, expected: 1},
+		"not annotated since":    {opts: ReadOptions{AnnotatedSince: &after}, expected: 0},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			conceptCh := make(chan Concept, readBufferSize)
+			count, _, err := neoSvc.Read(context.Background(), "Brand", test.opts, conceptCh)
+
+			assert.NoError(t, err, "Error reading from Neo")
+			assert.Equal(t, test.expected, count)
+		})
+	}
+}
+
+func TestNeoService_ReadChangesSince(t *testing.T) {
+	driver := getNeo4jDriver(t)
+
+	log := logger.NewUPPLogger("concept-exporter-test", "PANIC")
+	svc := concepts.NewConceptService(driver, log)
+	assert.NoError(t, svc.Initialise())
+
+	cleanDB(t, driver)
+	writeBrands(t, &svc)
+	writeContent(t, driver)
+	writeAnnotation(t, driver, fmt.Sprintf("./fixtures/Annotations-%s.json", contentUUID), "v1")
+
+	neoSvc := NewNeoService(driver, "not-needed")
+	// the child brand is the only annotated one, at 2016-01-20T19:43:47Z
+	since := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	deletedUUID := "00000000-0000-0000-0000-000000000000"
+	tests := map[string]struct {
+		baseline map[string][]string
+		expected map[string]string
+	}{
+		"without baseline": {
+			expected: map[string]string{brandChildUUID: Added},
+		},
+		"added": {
+			baseline: map[string][]string{"Brand": {}},
+			expected: map[string]string{brandChildUUID: Added},
+		},
+		"changed": {
+			baseline: map[string][]string{"Brand": {brandChildUUID}},
+			expected: map[string]string{brandChildUUID: Changed},
+		},
+		"removed": {
+			// sorted as the baseline of a job
+			baseline: map[string][]string{"Brand": {deletedUUID, brandParentUUID, brandChildUUID}},
+			expected: map[string]string{brandChildUUID: Changed, brandParentUUID: Removed, deletedUUID: Removed},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			conceptCh := make(chan Concept, readBufferSize)
+			count, _, err := neoSvc.Read(context.Background(), "Brand", ReadOptions{Since: &since, Baseline: test.baseline}, conceptCh)
+
+			assert.NoError(t, err, "Error reading from Neo")
+			assert.Equal(t, len(test.expected), count)
+			changes := map[string]string{}
+			for c := range conceptCh {
+				changes[c.UUID] = c.Change
+				assert.Equal(t, "http://api.ft.com/things/"+c.UUID, c.ID)
+			}
+			assert.Equal(t, test.expected, changes)
+		})
+	}
+}
+
+func TestNeoService_ReadUnannotated(t *testing.T) {
+	driver := getNeo4jDriver(t)
+
+	log := logger.NewUPPLogger("concept-exporter-test", "PANIC")
+	svc := concepts.NewConceptService(driver, log)
+	assert.NoError(t, svc.Initialise())
+
+	cleanDB(t, driver)
+	writeBrands(t, &svc)
+	writeContent(t, driver)
+	writeAnnotation(t, driver, fmt.Sprintf("./fixtures/Annotations-%s.json", contentUUID), "v1")
+
+	neoSvc := NewNeoService(driver, "not-needed")
+
+	conceptCh := make(chan Concept, readBufferSize)
+	count, _, err := neoSvc.Read(context.Background(), "Brand", ReadOptions{IncludeUnannotated: true}, conceptCh)
+
+	assert.NoError(t, err, "Error reading from Neo")
+	assert.Equal(t, 3, count)
+	annotated := map[string]bool{}
+	for c := range conceptCh {
+		require.NotNil(t, c.Annotated, c.UUID)
+		annotated[c.UUID] = *c.Annotated
+	}
+	assert.Equal(t, map[string]bool{brandParentUUID: false, brandChildUUID: true, brandGrandChildUUID: false}, annotated)
+}
+
+func TestNeoService_ReadWithConfiguredQuery(t *testing.T) {
+	driver := getNeo4jDriver(t)
+
+	log := logger.NewUPPLogger("concept-exporter-test", "PANIC")
+	svc := concepts.NewConceptService(driver, log)
+	assert.NoError(t, svc.Initialise())
+
+	cleanDB(t, driver)
+	writeBrands(t, &svc)
+	writeContent(t, driver)
+	writeAnnotation(t, driver, fmt.Sprintf("./fixtures/Annotations-%s-hasBrand.json", contentUUID), "v1")
+
+	neoSvc := NewNeoService(driver, "not-needed")
+	neoSvc.Queries = map[string]ConceptTypeQuery{
+		"Brand": {Predicates: AnnotatingPredicates, Fields: map[string]string{"ShortName": "'short ' + x.prefLabel"}},
+	}
+
+	conceptCh := make(chan Concept, readBufferSize)
+	count, found, err := neoSvc.Read(context.Background(), "Brand", ReadOptions{}, conceptCh)
+
+	assert.NoError(t, err, "Error reading from Neo")
+	assert.False(t, found, "brands of content should not be read without the HAS_BRAND predicate")
+	assert.Equal(t, 0, count)
+
+	neoSvc.Queries["Brand"] = ConceptTypeQuery{Fields: map[string]string{"ShortName": "'short ' + x.prefLabel"}}
+	conceptCh = make(chan Concept, readBufferSize)
+	count, _, err = neoSvc.Read(context.Background(), "Brand", ReadOptions{}, conceptCh)
+
+	assert.NoError(t, err, "Error reading from Neo")
+	assert.Equal(t, 1, count)
+	for c := range conceptCh {
+		assert.Equal(t, "short Business School video", c.ShortName)
+		assert.Equal(t, []string{"short Business School video"}, c.AlternativeLabels)
+	}
+}
+
 func TestNeoService_ReadOrganisation(t *testing.T) {
 	driver := getNeo4jDriver(t)
 
@@ -243,8 +562,8 @@ func TestNeoService_ReadOrganisation(t *testing.T) {
 			writeAnnotation(t, driver, fmt.Sprintf("./fixtures/Annotations-%s-org.json", contentUUID), "v2")
 			neoSvc := NewNeoService(driver, "not-needed")
 
-			conceptCh := make(chan Concept)
-			count, found, err := neoSvc.Read("Organisation", conceptCh)
+			conceptCh := make(chan Concept, readBufferSize)
+			count, found, err := neoSvc.Read(context.Background(), "Organisation", ReadOptions{}, conceptCh)
 
 			assert.NoError(t, err, "Error reading from Neo")
 			assert.True(t, found)
@@ -275,6 +594,88 @@ func TestNeoService_ReadOrganisation(t *testing.T) {
 	}
 }
 
+func TestNeoService_ReadConcordances(t *testing.T) {
+	driver := getNeo4jDriver(t)
+
+	log := logger.NewUPPLogger("concept-exporter-test", "PANIC")
+	svc := concepts.NewConceptService(driver, log)
+	assert.NoError(t, svc.Initialise())
+
+	cleanDB(t, driver)
+	// the organisation is not annotated, its concordances are read with the unannotated concepts only
+	writeJSONToConceptService(t, &svc, fmt.Sprintf("./fixtures/Organisation-Fakebook-%s-Factset2.json", companyUUID))
+
+	neoSvc := NewNeoService(driver, "not-needed")
+
+	concordanceCh := make(chan Concordance, readBufferSize)
+	count, err := neoSvc.ReadConcordances(context.Background(), "Organisation", ReadOptions{}, concordanceCh)
+	assert.NoError(t, err, "Error reading from Neo")
+	assert.Equal(t, 0, count)
+	_, open := <-concordanceCh
+	assert.False(t, open)
+
+	concordanceCh = make(chan Concordance, readBufferSize)
+	count, err = neoSvc.ReadConcordances(context.Background(), "Organisation", ReadOptions{IncludeUnannotated: true}, concordanceCh)
+
+	assert.NoError(t, err, "Error reading from Neo")
+	assert.Equal(t, 3, count)
+	var concordances []Concordance
+	for c := range concordanceCh {
+		concordances = append(concordances, c)
+	}
+	id := "http://api.ft.com/things/" + companyUUID
+	assert.Equal(t, []Concordance{
+		{ID: id, Authority: "FACTSET", AuthorityValue: "FACTSET1"},
+		{ID: id, Authority: "FACTSET", AuthorityValue: "FACTSET2"},
+		{ID: id, Authority: "Smartlogic", AuthorityValue: companyUUID},
+	}, concordances)
+}
+
+func TestNeoService_ReadConcordancesWithFilters(t *testing.T) {
+	driver := getNeo4jDriver(t)
+
+	log := logger.NewUPPLogger("concept-exporter-test", "PANIC")
+	svc := concepts.NewConceptService(driver, log)
+	assert.NoError(t, svc.Initialise())
+
+	cleanDB(t, driver)
+	writeBrands(t, &svc)
+	writeContent(t, driver)
+	writeAnnotation(t, driver, fmt.Sprintf("./fixtures/Annotations-%s-hasBrand.json", contentUUID), "v1")
+
+	neoSvc := NewNeoService(driver, "not-needed")
+	// the child brand has a single annotation, made at 2016-01-20T19:43:47Z
+	after := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		opts     ReadOptions
+		expected []string
+	}{
+		"annotated":              {opts: ReadOptions{}, expected: []string{brandChildUUID}},
+		"unannotated":            {opts: ReadOptions{IncludeUnannotated: true}, expected: []string{brandParentUUID, brandChildUUID, brandGrandChildUUID}},
+		"not enough annotations": {opts: ReadOptions{MinAnnotations: 2}},
+		"not annotated since":    {opts: ReadOptions{AnnotatedSince: &after}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			concordanceCh := make(chan Concordance, readBufferSize)
+			_, err := neoSvc.ReadConcordances(context.Background(), "Brand", test.opts, concordanceCh)
+
+			assert.NoError(t, err, "Error reading from Neo")
+			var ids []string
+			for c := range concordanceCh {
+				if len(ids) == 0 || ids[len(ids)-1] != c.ID {
+					ids = append(ids, c.ID)
+				}
+			}
+			var expected []string
+			for _, uuid := range test.expected {
+				expected = append(expected, "http://api.ft.com/things/"+uuid)
+			}
+			assert.Equal(t, expected, ids)
+		})
+	}
+}
+
 func TestNeoService_ReadOrganisationWithNAICS(t *testing.T) {
 	driver := getNeo4jDriver(t)
 
@@ -315,8 +716,8 @@ func TestNeoService_ReadOrganisationWithNAICS(t *testing.T) {
 			writeAnnotation(t, driver, fmt.Sprintf("./fixtures/Annotations-%s-org.json", contentUUID), "v2")
 			neoSvc := NewNeoService(driver, "not-needed")
 
-			conceptCh := make(chan Concept)
-			count, found, err := neoSvc.Read("Organisation", conceptCh)
+			conceptCh := make(chan Concept, readBufferSize)
+			count, found, err := neoSvc.Read(context.Background(), "Organisation", ReadOptions{}, conceptCh)
 
 			assert.NoError(t, err, "Error reading from Neo")
 			assert.True(t, found)
@@ -390,8 +791,8 @@ func TestNeoService_ReadPerson(t *testing.T) {
 			writeAnnotation(t, driver, test.annotationsFixture, "pac")
 			neoSvc := NewNeoService(driver, "not-needed")
 
-			conceptCh := make(chan Concept)
-			count, found, err := neoSvc.Read(test.readAs, conceptCh)
+			conceptCh := make(chan Concept, readBufferSize)
+			count, found, err := neoSvc.Read(context.Background(), test.readAs, ReadOptions{}, conceptCh)
 
 			assert.NoError(t, err, "Error reading from Neo")
 			assert.Equal(t, test.expectedCount, count)
@@ -420,13 +821,52 @@ func TestNeoService_ReadPerson(t *testing.T) {
 	}
 }
 
+func TestNeoService_ReadLocation(t *testing.T) {
+	driver := getNeo4jDriver(t)
+
+	log := logger.NewUPPLogger("concept-exporter-test", "PANIC")
+	svc := concepts.NewConceptService(driver, log)
+	assert.NoError(t, svc.Initialise())
+
+	cleanDB(t, driver)
+	// London is in the United Kingdom, which is in Europe
+	writeJSONToConceptService(t, &svc, fmt.Sprintf("./fixtures/Location-%s-region.json", regionUUID))
+	writeJSONToConceptService(t, &svc, fmt.Sprintf("./fixtures/Location-%s-country.json", countryUUID))
+	writeJSONToConceptService(t, &svc, fmt.Sprintf("./fixtures/Location-%s-city.json", cityUUID))
+
+	neoSvc := NewNeoService(driver, "not-needed")
+
+	conceptCh := make(chan Concept, readBufferSize)
+	count, _, err := neoSvc.Read(context.Background(), "Location", ReadOptions{IncludeUnannotated: true}, conceptCh)
+
+	assert.NoError(t, err, "Error reading from Neo")
+	assert.Equal(t, 3, count)
+	locations := map[string]Concept{}
+	for c := range conceptCh {
+		locations[c.UUID] = c
+	}
+	require.Len(t, locations, 3)
+
+	assert.Empty(t, locations[regionUUID].ISO31661)
+	assert.Empty(t, locations[regionUUID].BroaderUUIDs)
+	assert.Equal(t, []string{countryUUID}, locations[regionUUID].NarrowerUUIDs)
+
+	assert.Equal(t, "GB", locations[countryUUID].ISO31661)
+	assert.Equal(t, []string{regionUUID}, locations[countryUUID].BroaderUUIDs)
+	assert.Equal(t, []string{cityUUID}, locations[countryUUID].NarrowerUUIDs)
+
+	assert.Empty(t, locations[cityUUID].ISO31661)
+	assert.Equal(t, []string{countryUUID, regionUUID}, locations[cityUUID].BroaderUUIDs, "the nearest broader location should be first")
+	assert.Empty(t, locations[cityUUID].NarrowerUUIDs)
+}
+
 func TestNeoService_ReadWithoutResult(t *testing.T) {
 	driver := getNeo4jDriver(t)
 	cleanDB(t, driver)
 	neoSvc := NewNeoService(driver, "not-needed")
 
-	conceptCh := make(chan Concept)
-	count, found, err := neoSvc.Read("Brand", conceptCh)
+	conceptCh := make(chan Concept, readBufferSize)
+	count, found, err := neoSvc.Read(context.Background(), "Brand", ReadOptions{}, conceptCh)
 
 	assert.NoError(t, err, "Error reading from Neo")
 	assert.False(t, found)
@@ -449,8 +889,8 @@ func TestNeoService_ReadWithError(t *testing.T) {
 	driver := getNeo4jDriver(t)
 	neoSvc := NewNeoService(driver, "not-needed")
 
-	conceptCh := make(chan Concept)
-	count, found, err := neoSvc.Read("Invalid Concept", conceptCh)
+	conceptCh := make(chan Concept, readBufferSize)
+	count, found, err := neoSvc.Read(context.Background(), "Invalid Concept", ReadOptions{}, conceptCh)
 
 	assert.Error(t, err, "Expected an error when reading from Neo")
 	assert.False(t, found)
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertStatementContains asserts that the Cypher statement contains the fragment, whatever their whitespace
+func assertStatementContains(t *testing.T, stmt, fragment string, msgAndArgs ...interface{}) bool {
+	t.Helper()
+	return assert.Contains(t, normalizeSpace(stmt), normalizeSpace(fragment), msgAndArgs...)
+}
+
+// assertStatementNotContains asserts that the Cypher statement doesn't contain the fragment, whatever their whitespace
+func assertStatementNotContains(t *testing.T, stmt, fragment string, msgAndArgs ...interface{}) bool {
+	t.Helper()
+	return assert.NotContains(t, normalizeSpace(stmt), normalizeSpace(fragment), msgAndArgs...)
+}
+
+// normalizeSpace replaces every sequence of whitespace with a single space
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func TestGetReadStatement_AnnotationStats(t *testing.T) {
+	assertStatementNotContains(t, getReadStatement("Person", DefaultQueries["Person"], ReadOptions{}), "AS Annotations")
+
+	stmt := getReadStatement("Person", DefaultQueries["Person"], ReadOptions{AnnotationStats: true})
+	assertStatementContains(t, stmt, "[(x)<-[:EQUIVALENT_TO]-(:Concept)<-[rel:`MENTIONS`|`MAJOR_MENTIONS`|`ABOUT`|`IS_CLASSIFIED_BY`|`IS_PRIMARILY_CLASSIFIED_BY`|`HAS_AUTHOR`]-(:Content)")
+	assertStatementContains(t, stmt, "}][0] AS Annotations, Change")
+}
+
+func TestGetReadStatement_Filters(t *testing.T) {
+	annotatedSince := time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)
+	opts := ReadOptions{MinAnnotations: 5, AnnotatedSince: &annotatedSince, ExcludeDeprecated: true}
+
+	stmt := getSelectionStatement("Brand", DefaultQuery, opts)
+	assertStatementContains(t, stmt, "WHERE x.prefUUID > $lastUUID AND NOT coalesce(x.isDeprecated, false) WITH x ORDER BY x.prefUUID LIMIT $pageSize OPTIONAL MATCH (x)<-[:EQUIVALENT_TO]-",
+		"the window of the page should be cut before its annotations are counted")
+	assertStatementContains(t, stmt, "CASE WHEN annotations > 0 AND annotations >= $minAnnotations AND lastAnnotated >= $annotatedSince THEN {Uuid: x.prefUUID, Change: null} END")
+	assert.Equal(t, map[string]interface{}{"minAnnotations": 5, "annotatedSince": annotatedSince.Unix()}, getReadParams(opts))
+
+	opts.Since = &annotatedSince
+	stmt = getSelectionStatement("Brand", DefaultQuery, opts)
+	assertStatementContains(t, stmt, "annotations >= $minAnnotations AND lastAnnotated >= $annotatedSince AND (x.lastModifiedEpoch > $since OR lastAnnotated > $since) "+
+		"THEN {Uuid: x.prefUUID, Change: CASE WHEN firstAnnotated > $since THEN 'added' ELSE 'changed' END} END")
+
+	assertStatementContains(t, getSelectionStatement("Brand", DefaultQuery, ReadOptions{MinAnnotations: 1}), "CASE WHEN annotations > 0 THEN {Uuid: x.prefUUID, Change: null} END",
+		"every exported concept has at least one annotation")
+	assert.Empty(t, getReadParams(ReadOptions{MinAnnotations: 1}))
+}
+
+func TestGetReadStatement_IncludeUnannotated(t *testing.T) {
+	opts := ReadOptions{IncludeUnannotated: true, ExcludeDeprecated: true}
+	assertStatementContains(t, getSelectionStatement("Topic", DefaultQuery, opts), "MATCH (x:`Topic`) WHERE x.prefUUID > $lastUUID AND NOT coalesce(x.isDeprecated, false) WITH x ORDER BY x.prefUUID LIMIT $pageSize "+
+		"WITH max(x.prefUUID) AS Cursor, count(x) AS Scanned, collect({Uuid: x.prefUUID, Change: null}) AS Page RETURN Cursor, Scanned, Page")
+	stmt := getReadStatement("Topic", DefaultQuery, opts)
+	assertStatementContains(t, stmt, "exists((x)<-[:EQUIVALENT_TO]-(:Concept)<-[:`MENTIONS`|")
+	assertStatementContains(t, stmt, "]-(:Content)) AS Annotated")
+
+	stmt = getSelectionStatement("Topic", DefaultQuery, ReadOptions{IncludeUnannotated: true, MinAnnotations: 2})
+	assertStatementContains(t, stmt, "<-[rel:`MENTIONS`|", "the unannotated concepts can't have enough annotations")
+}
+
+func TestGetReadStatement_Location(t *testing.T) {
+	s := NewNeoService(nil, "not-needed")
+	q := s.getQuery("Location")
+	require.NoError(t, s.checkQuery("Location", q))
+
+	stmt := getReadStatement("Location", q, ReadOptions{})
+	assertStatementContains(t, stmt, "x.iso31661 AS ISO31661")
+	assertStatementContains(t, stmt, "broaderUUIDs AS BroaderUUIDs")
+	assertStatementContains(t, stmt, "WITH x, Change, collect(DISTINCT broader1.prefUUID)")
+}
+
+func TestOtherUUIDs(t *testing.T) {
+	assert.Equal(t, []string{"b", "c"}, otherUUIDs([]string{"b", "a", "c", "b"}, "a"))
+	assert.Empty(t, otherUUIDs(nil, "a"))
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Financial-Times/neo-model-utils-go/mapper"
+)
+
+// RelationshipService reads the relationships between canonical concepts.
+// ReadRelationships blocks until every relationship of the concepts of the given type has been sent on the channel
+// and returns the final count. It stops early with the context's error when the context is cancelled.
+type RelationshipService interface {
+	ReadRelationships(ctx context.Context, conceptType string, opts ReadOptions, relCh chan Relationship) (int, error)
+}
+
+// Relationship is an edge between two canonical concepts, both identified by the ID URL of their prefUUID
+type Relationship struct {
+	SourceID  string
+	Predicate string
+	TargetID  string
+}
+
+// RelationshipPredicates are the relationships between concepts which are exported as they are,
+// read from the source concepts of the exported concepts
+var RelationshipPredicates = []string{
+	"HAS_PARENT",
+	"HAS_BROADER",
+	"IS_RELATED_TO",
+	"SUPERSEDED_BY",
+	"IMPLIED_BY",
+	"HAS_FOCUS",
+	"SUB_ORGANISATION_OF",
+	"HAS_INDUSTRY_CLASSIFICATION",
+	"COUNTRY_OF_INCORPORATION",
+	"COUNTRY_OF_OPERATIONS",
+	"COUNTRY_OF_RISK",
+}
+
+// MembershipPredicate is the predicate of the relationships exported between a person and the organisations of their memberships.
+// They are read through the Membership nodes, (:Person)<-[:HAS_MEMBER]-(:Membership)-[:HAS_ORGANISATION]->(:Organisation),
+// which are not concepts of their own in the export.
+const MembershipPredicate = "IS_MEMBER_OF"
+
+// relationshipsRow has the relationships of a canonical concept, read from all its source concepts
+type relationshipsRow struct {
+	UUID          string `json:"Uuid"`
+	Relationships []struct {
+		Predicate string `json:"predicate"`
+		Target    string `json:"target"`
+	} `json:"Relationships"`
+}
+
+// ReadRelationships reads the relationships of the annotated canonical concepts of the given type,
+// the same concepts Read returns for a full export with the given options
+func (s *NeoService) ReadRelationships(ctx context.Context, conceptType string, opts ReadOptions, relCh chan Relationship) (int, error) {
+	defer close(relCh)
+	opts.Since = nil
+
+	q := s.getQuery(conceptType)
+	if err := s.checkQuery(conceptType, q); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	_, err := readPages(ctx, s, getSelectionStatement(conceptType, q, opts), getRelationshipsStatement(conceptType), getReadParams(opts), func(row relationshipsRow) error {
+		sort.Slice(row.Relationships, func(i, j int) bool {
+			if row.Relationships[i].Predicate != row.Relationships[j].Predicate {
+				return row.Relationships[i].Predicate < row.Relationships[j].Predicate
+			}
+			return row.Relationships[i].Target < row.Relationships[j].Target
+		})
+		for _, rel := range row.Relationships {
+			select {
+			case relCh <- Relationship{SourceID: mapper.IDURL(row.UUID), Predicate: rel.Predicate, TargetID: mapper.IDURL(rel.Target)}:
+				count++
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// getRelationshipsStatement returns the Cypher reading the relationships of the canonical concepts selected for a page.
+// The relationships of the source concepts are resolved to the canonical concepts their targets are equivalent to,
+// and the memberships of the source people to the canonical organisations, see MembershipPredicate.
+func getRelationshipsStatement(conceptType string) string {
+	return getPageMatch(conceptType) + fmt.Sprintf(`OPTIONAL MATCH (x)<-[:EQUIVALENT_TO]-(:Concept)-[edge:%s]->()-[:EQUIVALENT_TO]->(target)
+		WHERE target.prefUUID <> x.prefUUID
+		WITH x, collect(DISTINCT CASE WHEN target IS NOT NULL THEN {predicate: type(edge), target: target.prefUUID} END) AS relationships
+		OPTIONAL MATCH (x)<-[:EQUIVALENT_TO]-(:Concept)<-[:HAS_MEMBER]-(:Membership)-[:HAS_ORGANISATION]->()-[:EQUIVALENT_TO]->(organisation:Organisation)
+		WITH x, relationships, collect(DISTINCT organisation.prefUUID) AS organisations
+		RETURN x.prefUUID AS Uuid, relationships + [uuid IN organisations | {predicate: '%s', target: uuid}] AS Relationships
+		ORDER BY Uuid
+		`, quoteIdentifiers(RelationshipPredicates), MembershipPredicate)
+}
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadRelationships_RejectsLabels(t *testing.T) {
+	s := NewNeoService(nil, "")
+	relCh := make(chan Relationship)
+	count, err := s.ReadRelationships(context.Background(), "Genre", ReadOptions{}, relCh)
+
+	assert.ErrorIs(t, err, ErrLabelNotAllowed)
+	assert.Equal(t, 0, count)
+	_, open := <-relCh
+	assert.False(t, open, "the channel should be closed")
+}
+
+func TestGetRelationshipsStatement_QuotesIdentifiers(t *testing.T) {
+	stmt := getRelationshipsStatement("Brand")
+	assert.Contains(t, stmt, "MATCH (x:`Brand`)\n")
+	assert.Contains(t, stmt, "-[edge:`HAS_PARENT`|`HAS_BROADER`|")
+	assert.NotContains(t, stmt, "`HAS_MEMBERSHIP`")
+	assert.Contains(t, stmt, "(x)<-[:EQUIVALENT_TO]-(:Concept)<-[:HAS_MEMBER]-(:Membership)-[:HAS_ORGANISATION]->()-[:EQUIVALENT_TO]->(organisation:Organisation)",
+		"the memberships should be read through the Membership nodes")
+	assert.Contains(t, stmt, "{predicate: 'IS_MEMBER_OF', target: uuid}")
+}
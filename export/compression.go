@@ -0,0 +1,107 @@
+package export
+
+import (
+	"compress/gzip"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compressions the exported files can be written with
+const (
+	NoCompression   = "none"
+	GzipCompression = "gzip"
+	ZstdCompression = "zstd"
+)
+
+var compressionExtensions = map[string]string{
+	GzipCompression: ".gz",
+	ZstdCompression: ".zst",
+}
+
+// IsSupportedCompression tells whether the exported files can be written with the given compression
+func IsSupportedCompression(compression string) bool {
+	_, found := compressionExtensions[compression]
+	return found || compression == NoCompression
+}
+
+// compressedExporter compresses the files written by an exporter, adding the extension of the compression to their names
+type compressedExporter struct {
+	Exporter
+	compression string
+	writers     map[string]io.WriteCloser
+}
+
+// withCompression returns the exporter writing files with the given supported compression
+func withCompression(newExporter NewExporterFunc, compression string) NewExporterFunc {
+	if _, found := compressionExtensions[compression]; !found {
+		return newExporter
+	}
+	return func() Exporter {
+		return &compressedExporter{Exporter: newExporter(), compression: compression}
+	}
+}
+
+func (e *compressedExporter) Prepare(writers map[string]io.Writer) error {
+	e.writers = make(map[string]io.WriteCloser, len(writers))
+	compressed := make(map[string]io.Writer, len(writers))
+	for cType, w := range writers {
+		cw, err := e.newWriter(w)
+		if err != nil {
+			return err
+		}
+		e.writers[cType] = cw
+		compressed[cType] = cw
+	}
+	return e.Exporter.Prepare(compressed)
+}
+
+func (e *compressedExporter) newWriter(w io.Writer) (io.WriteCloser, error) {
+	return compressWriter(w, e.compression)
+}
+
+// compressWriter returns the writer compressing into w with the given compression, which has to be closed to end the stream
+func compressWriter(w io.Writer, compression string) (io.WriteCloser, error) {
+	switch compression {
+	case GzipCompression:
+		return gzip.NewWriter(w), nil
+	case ZstdCompression:
+		return zstd.NewWriter(w)
+	}
+	return nopWriteCloser{w}, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// Flush ends the compressed stream of the concept type, nothing can be written to it afterwards
+func (e *compressedExporter) Flush(conceptType string) error {
+	if err := e.Exporter.Flush(conceptType); err != nil {
+		return err
+	}
+	return e.writers[conceptType].Close()
+}
+
+func (e *compressedExporter) GetFileName(conceptType string) string {
+	return e.Exporter.GetFileName(conceptType) + compressionExtensions[e.compression]
+}
+
+// decompress returns the reader of the content of a file written with the given compression
+func decompress(r io.Reader, compression string) (io.ReadCloser, error) {
+	switch compression {
+	case GzipCompression:
+		return gzip.NewReader(r)
+	case ZstdCompression:
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	}
+	return io.NopCloser(r), nil
+}
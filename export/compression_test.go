@@ -0,0 +1,65 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/Financial-Times/concept-exporter/db"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressedExporter(t *testing.T) {
+	tests := []struct {
+		compression string
+		fileName    string
+		decompress  func(r io.Reader) (io.Reader, error)
+	}{
+		{
+			compression: GzipCompression,
+			fileName:    "Brand.csv.gz",
+			decompress: func(r io.Reader) (io.Reader, error) {
+				return gzip.NewReader(r)
+			},
+		},
+		{
+			compression: ZstdCompression,
+			fileName:    "Brand.csv.zst",
+			decompress: func(r io.Reader) (io.Reader, error) {
+				return zstd.NewReader(r)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.compression, func(t *testing.T) {
+			brands := new(bytes.Buffer)
+			exporter := withCompression(SupportedExporters()[CSVFormat], test.compression)()
+			require.NoError(t, exporter.Prepare(map[string]io.Writer{"Brand": brands}))
+			require.NoError(t, exporter.Write(db.Concept{ID: "http://api.ft.com/things/1", PrefLabel: "FT"}, "Brand", "tid_1234"))
+			require.NoError(t, exporter.Flush("Brand"))
+			assert.Equal(t, test.fileName, exporter.GetFileName("Brand"))
+
+			r, err := test.decompress(brands)
+			require.NoError(t, err)
+			content, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, "id,prefLabel,apiUrl,alternativeLabels\nhttp://api.ft.com/things/1,FT,,\n", string(content))
+		})
+	}
+}
+
+func TestWithCompression_None(t *testing.T) {
+	exporter := withCompression(SupportedExporters()[CSVFormat], NoCompression)()
+	assert.IsType(t, &CsvExporter{}, exporter)
+	assert.Equal(t, "Brand.csv", exporter.GetFileName("Brand"))
+}
+
+func TestIsSupportedCompression(t *testing.T) {
+	assert.True(t, IsSupportedCompression(NoCompression))
+	assert.True(t, IsSupportedCompression(GzipCompression))
+	assert.True(t, IsSupportedCompression(ZstdCompression))
+	assert.False(t, IsSupportedCompression("brotli"))
+}
@@ -0,0 +1,33 @@
+package export
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Financial-Times/concept-exporter/db"
+)
+
+// ConcordanceFile is the name the concordance file of a job is reported under, in place of a concept type
+const ConcordanceFile = "concordance"
+
+// concordanceColumns are the columns of the concordance file
+var concordanceColumns = []string{"id", "authority", "authorityValue"}
+
+// concordanceFile returns the file of the concordances of the concepts read with the given options
+func (fe *FullExporter) concordanceFile(opts db.ReadOptions) recordFile {
+	return recordFile{
+		name:    ConcordanceFile,
+		columns: concordanceColumns,
+		read: func(ctx context.Context, conceptType string, write func(record []string) error) error {
+			if fe.Concordances == nil {
+				return errors.New("concordances can't be read")
+			}
+			read := func(ctx context.Context, conceptType string, concordanceCh chan db.Concordance) (int, error) {
+				return fe.Concordances.ReadConcordances(ctx, conceptType, opts, concordanceCh)
+			}
+			return readChannel(ctx, conceptType, read, func(c db.Concordance) error {
+				return write([]string{c.ID, c.Authority, c.AuthorityValue})
+			})
+		},
+	}
+}
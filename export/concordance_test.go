@@ -0,0 +1,60 @@
+package export
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Financial-Times/concept-exporter/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockConcordanceService struct {
+	concordances map[string][]db.Concordance
+	// opts are the options of the last read
+	opts db.ReadOptions
+}
+
+func (m *mockConcordanceService) ReadConcordances(ctx context.Context, conceptType string, opts db.ReadOptions, concordanceCh chan db.Concordance) (int, error) {
+	defer close(concordanceCh)
+	m.opts = opts
+	for _, c := range m.concordances[conceptType] {
+		concordanceCh <- c
+	}
+	return len(m.concordances[conceptType]), nil
+}
+
+func TestFullExporter_RunFullExportWithConcordance(t *testing.T) {
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, mock.Anything, "Organisation.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, "Location.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, []byte("id,authority,authorityValue\n"+
+		"http://api.ft.com/things/1,FACTSET,000C7F-E\n"+
+		"http://api.ft.com/things/1,TME,TnN0ZWluX09OX0FGVE1fT05fMjMxNjA=-T04=\n"+
+		"http://api.ft.com/things/2,Wikidata,http://www.wikidata.org/entity/Q84\n"), "concordance.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, ManifestFileName, "tid_1234").Return(nil)
+	concordances := &mockConcordanceService{concordances: map[string][]db.Concordance{
+		"Organisation": {
+			{ID: "http://api.ft.com/things/1", Authority: "FACTSET", AuthorityValue: "000C7F-E"},
+			{ID: "http://api.ft.com/things/1", Authority: "TME", AuthorityValue: "TnN0ZWluX09OX0FGVE1fT05fMjMxNjA=-T04="},
+		},
+		"Location": {{ID: "http://api.ft.com/things/2", Authority: "Wikidata", AuthorityValue: "http://www.wikidata.org/entity/Q84"}},
+	}}
+	options := Options{Concordance: true, MinAnnotations: 5, ExcludeDeprecated: true}
+	_, result := runJob(t, updater, &mockInquirer{}, []string{"Organisation", "Location"}, options, func(fe *FullExporter) {
+		fe.Concordances = concordances
+	})
+
+	assert.Empty(t, result.Failed)
+	assert.Contains(t, result.Files, "concordance.csv")
+	assert.Equal(t, db.ReadOptions{MinAnnotations: 5, ExcludeDeprecated: true}, concordances.opts, "the concordances should be read for the exported concepts")
+	updater.AssertExpectations(t)
+}
+
+func TestFullExporter_RunFullExportWithoutConcordanceService(t *testing.T) {
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, mock.Anything, mock.Anything, "tid_1234").Return(nil)
+	_, result := runJob(t, updater, &mockInquirer{}, []string{"Brand"}, Options{Concordance: true, Relationships: true})
+
+	assert.Equal(t, []string{RelationshipsFile, ConcordanceFile}, result.Failed)
+}
@@ -1,37 +1,211 @@
 package export
 
 import (
-	"bytes"
 	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Financial-Times/concept-exporter/db"
 )
 
 type CsvExporter struct {
-	Writer map[string]*ConceptWriter
-}
-
-type ConceptWriter struct {
-	Buffer *bytes.Buffer
-	Writer *csv.Writer
+	Writer map[string]*csv.Writer
+	// Columns overrides the default columns of the concept types
+	Columns map[string][]string
 }
 
 func NewCsvExporter() *CsvExporter {
 	return &CsvExporter{}
 }
 
-func (e *CsvExporter) GetBytes(conceptType string) []byte {
-	e.Writer[conceptType].Writer.Flush()
-	return e.Writer[conceptType].Buffer.Bytes()
+// csvColumns are the columns a CSV file can have, each with the value it gets from a concept
+var csvColumns = map[string]func(c db.Concept) string{
+	"id":                func(c db.Concept) string { return c.ID },
+	"uuid":              func(c db.Concept) string { return c.UUID },
+	"prefLabel":         func(c db.Concept) string { return c.PrefLabel },
+	"apiUrl":            func(c db.Concept) string { return c.APIURL },
+	"labels":            func(c db.Concept) string { return strings.Join(c.Labels, ";") },
+	"alternativeLabels": func(c db.Concept) string { return strings.Join(c.AlternativeLabels, ";") },
+	"aliases":           func(c db.Concept) string { return strings.Join(c.Aliases, ";") },
+	"formerNames":       func(c db.Concept) string { return strings.Join(c.FormerNames, ";") },
+	"tradeNames":        func(c db.Concept) string { return strings.Join(c.TradeNames, ";") },
+	"properName":        func(c db.Concept) string { return c.ProperName },
+	"shortName":         func(c db.Concept) string { return c.ShortName },
+	"leiCode":           func(c db.Concept) string { return c.LeiCode },
+	"factsetId":         func(c db.Concept) string { return strings.Join(c.FactsetIDs, ";") },
+	"FIGI":              func(c db.Concept) string { return strings.Join(c.FigiCodes, ";") },
+	"NAICS": func(c db.Concept) string {
+		var naics []string
+		for _, ic := range c.NAICSIndustryClassifications {
+			naics = append(naics, ic.IndustryIdentifier)
+		}
+		return strings.Join(naics, ";")
+	},
+	// NAICSRank has the ranks of the industry classifications in the order of the NAICS column
+	"NAICSRank": func(c db.Concept) string {
+		var ranks []string
+		for _, ic := range c.NAICSIndustryClassifications {
+			ranks = append(ranks, strconv.Itoa(ic.Rank))
+		}
+		return strings.Join(ranks, ";")
+	},
+	"mentions":       annotationCount(func(a *db.AnnotationStats) int { return a.Mentions }),
+	"about":          annotationCount(func(a *db.AnnotationStats) int { return a.About }),
+	"isClassifiedBy": annotationCount(func(a *db.AnnotationStats) int { return a.IsClassifiedBy }),
+	"hasAuthor":      annotationCount(func(a *db.AnnotationStats) int { return a.HasAuthor }),
+	"hasBrand":       annotationCount(func(a *db.AnnotationStats) int { return a.HasBrand }),
+	"firstAnnotated": func(c db.Concept) string {
+		if c.Annotations == nil {
+			return ""
+		}
+		return formatEpoch(c.Annotations.FirstAnnotated)
+	},
+	"lastAnnotated": func(c db.Concept) string {
+		if c.Annotations == nil {
+			return ""
+		}
+		return formatEpoch(c.Annotations.LastAnnotated)
+	},
+	"iso31661":      func(c db.Concept) string { return c.ISO31661 },
+	"broaderUUIDs":  func(c db.Concept) string { return strings.Join(c.BroaderUUIDs, ";") },
+	"narrowerUUIDs": func(c db.Concept) string { return strings.Join(c.NarrowerUUIDs, ";") },
+	// annotated is empty unless the job includes unannotated concepts
+	"annotated": func(c db.Concept) string {
+		if c.Annotated == nil {
+			return ""
+		}
+		return strconv.FormatBool(*c.Annotated)
+	},
 }
 
-func (e *CsvExporter) Prepare(conceptTypes []string) error {
-	writer := make(map[string]*ConceptWriter, len(conceptTypes))
+// AnnotatedColumn tells whether a concept is annotated, it is added to the columns of the jobs including unannotated concepts
+const AnnotatedColumn = "annotated"
+
+// AnnotationStatsColumns are the columns of the annotation usage statistics, empty unless the job reads them
+var AnnotationStatsColumns = []string{"mentions", "about", "isClassifiedBy", "hasAuthor", "hasBrand", "firstAnnotated", "lastAnnotated"}
+
+func annotationCount(count func(a *db.AnnotationStats) int) func(c db.Concept) string {
+	return func(c db.Concept) string {
+		if c.Annotations == nil {
+			return ""
+		}
+		return strconv.Itoa(count(c.Annotations))
+	}
+}
+
+// formatEpoch returns the RFC3339 UTC time of the epoch seconds, empty for 0
+func formatEpoch(epoch int64) string {
+	if epoch == 0 {
+		return ""
+	}
+	return time.Unix(epoch, 0).UTC().Format(time.RFC3339)
+}
+
+// DefaultCSVColumns are the columns of the concept types without columns of their own in DefaultCSVColumnsByType
+var DefaultCSVColumns = []string{"id", "prefLabel", "apiUrl", "alternativeLabels"}
+
+// DefaultCSVColumnsByType are the built-in columns of the concept types which don't have DefaultCSVColumns
+var DefaultCSVColumnsByType = map[string][]string{
+	"Organisation": {"id", "prefLabel", "apiUrl", "alternativeLabels", "leiCode", "factsetId", "FIGI", "NAICS"},
+	"Location":     {"id", "prefLabel", "apiUrl", "alternativeLabels", "iso31661", "broaderUUIDs", "narrowerUUIDs"},
+}
+
+// IsSupportedCSVColumn tells whether a CSV file can have the given column
+func IsSupportedCSVColumn(column string) bool {
+	_, found := csvColumns[column]
+	return found
+}
+
+// SupportedCSVColumns returns the sorted columns a CSV file can have
+func SupportedCSVColumns() []string {
+	columns := make([]string, 0, len(csvColumns))
+	for column := range csvColumns {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// mergeCSVColumns returns the columns of the concept types, those of overrides replacing the default ones
+func mergeCSVColumns(defaults, overrides map[string][]string) map[string][]string {
+	if len(overrides) == 0 {
+		return defaults
+	}
+	columns := make(map[string][]string, len(defaults)+len(overrides))
+	for cType, c := range defaults {
+		columns[cType] = c
+	}
+	for cType, c := range overrides {
+		columns[cType] = c
+	}
+	return columns
+}
+
+// appendCSVColumns returns the columns of the concept types with the given columns they don't have appended
+func appendCSVColumns(columns map[string][]string, conceptTypes []string, extra ...string) map[string][]string {
+	result := make(map[string][]string, len(columns)+len(conceptTypes))
+	for cType, c := range columns {
+		result[cType] = c
+	}
+	for _, cType := range conceptTypes {
+		c := append([]string{}, csvColumnsOf(columns, cType)...)
+		for _, column := range extra {
+			if !slices.Contains(c, column) {
+				c = append(c, column)
+			}
+		}
+		result[cType] = c
+	}
+	return result
+}
+
+// hasAnnotationStatsColumns tells whether any of the concept types has some of the AnnotationStatsColumns
+func hasAnnotationStatsColumns(columns map[string][]string, conceptTypes []string) bool {
 	for _, cType := range conceptTypes {
-		buffer := new(bytes.Buffer)
-		writer[cType] = &ConceptWriter{Buffer: buffer, Writer: csv.NewWriter(buffer)}
-		err := writer[cType].Writer.Write(getHeader(cType))
+		for _, column := range csvColumnsOf(columns, cType) {
+			if slices.Contains(AnnotationStatsColumns, column) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withCSVColumns returns the exporter writing the given columns of the concept types if it writes CSV
+func withCSVColumns(newExporter NewExporterFunc, columns map[string][]string) NewExporterFunc {
+	if len(columns) == 0 {
+		return newExporter
+	}
+	return func() Exporter {
+		exporter := newExporter()
+		if csvExporter, ok := exporter.(*CsvExporter); ok {
+			csvExporter.Columns = columns
+		}
+		return exporter
+	}
+}
+
+func (e *CsvExporter) Flush(conceptType string) error {
+	e.Writer[conceptType].Flush()
+	return e.Writer[conceptType].Error()
+}
+
+func (e *CsvExporter) Prepare(writers map[string]io.Writer) error {
+	writer := make(map[string]*csv.Writer, len(writers))
+	for cType, w := range writers {
+		header := e.getHeader(cType)
+		for _, column := range header {
+			if !IsSupportedCSVColumn(column) {
+				return fmt.Errorf("unsupported CSV column %v of %v", column, cType)
+			}
+		}
+		writer[cType] = csv.NewWriter(w)
+		err := writer[cType].Write(header)
 		if err != nil {
 			return err
 		}
@@ -41,39 +215,37 @@ func (e *CsvExporter) Prepare(conceptTypes []string) error {
 }
 
 func (e *CsvExporter) Write(c db.Concept, conceptType, tid string) error {
-	rec := conceptToCSVRecord(c, conceptType)
-	return e.Writer[conceptType].Writer.Write(rec)
+	rec := conceptToCSVRecord(c, e.getHeader(conceptType))
+	return e.Writer[conceptType].Write(rec)
 }
 
 func (e *CsvExporter) GetFileName(conceptType string) string {
 	return conceptType + ".csv"
 }
 
-func getHeader(conceptType string) []string {
-	if conceptType == "Organisation" {
-		return []string{"id", "prefLabel", "apiUrl", "alternativeLabels", "leiCode", "factsetId", "FIGI", "NAICS"}
-	}
-	return []string{"id", "prefLabel", "apiUrl", "alternativeLabels"}
+func (e *CsvExporter) GetColumns(conceptType string) []string {
+	return e.getHeader(conceptType)
 }
 
-func conceptToCSVRecord(c db.Concept, conceptType string) []string {
-	var rec []string
-	rec = append(rec, c.ID)
-	rec = append(rec, c.PrefLabel)
-	rec = append(rec, c.APIURL)
-	rec = append(rec, strings.Join(c.AlternativeLabels, ";"))
-	if conceptType == "Organisation" {
-		rec = append(rec, c.LeiCode)
-		rec = append(rec, strings.Join(c.FactsetIDs, ";"))
-		rec = append(rec, strings.Join(c.FigiCodes, ";"))
-
-		var naics []string
-		for _, ic := range c.NAICSIndustryClassifications {
-			naics = append(naics, ic.IndustryIdentifier)
-		}
+func (e *CsvExporter) getHeader(conceptType string) []string {
+	return csvColumnsOf(e.Columns, conceptType)
+}
 
-		rec = append(rec, strings.Join(naics, ";"))
+// csvColumnsOf returns the columns of the concept type, those of DefaultCSVColumnsByType or DefaultCSVColumns if it has none
+func csvColumnsOf(columns map[string][]string, conceptType string) []string {
+	if c, found := columns[conceptType]; found {
+		return c
 	}
+	if c, found := DefaultCSVColumnsByType[conceptType]; found {
+		return c
+	}
+	return DefaultCSVColumns
+}
 
+func conceptToCSVRecord(c db.Concept, columns []string) []string {
+	rec := make([]string, 0, len(columns))
+	for _, column := range columns {
+		rec = append(rec, csvColumns[column](c))
+	}
 	return rec
 }
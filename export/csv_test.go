@@ -1,20 +1,26 @@
 package export
 
 import (
+	"bytes"
+	"io"
 	"testing"
 
 	"github.com/Financial-Times/concept-exporter/db"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var supportedConceptTypes = []string{"Brand", "Topic", "Location", "Person", "Organisation"}
 
 func TestGetHeader(t *testing.T) {
 	for _, conceptType := range supportedConceptTypes {
-		header := getHeader(conceptType)
-		if conceptType == "Organisation" {
+		header := NewCsvExporter().getHeader(conceptType)
+		switch conceptType {
+		case "Organisation":
 			assert.Equal(t, []string{"id", "prefLabel", "apiUrl", "alternativeLabels", "leiCode", "factsetId", "FIGI", "NAICS"}, header)
-		} else {
+		case "Location":
+			assert.Equal(t, []string{"id", "prefLabel", "apiUrl", "alternativeLabels", "iso31661", "broaderUUIDs", "narrowerUUIDs"}, header)
+		default:
 			assert.Equal(t, []string{"id", "prefLabel", "apiUrl", "alternativeLabels"}, header)
 		}
 	}
@@ -66,12 +72,112 @@ func TestConceptToCSVRecord(t *testing.T) {
 				"519130;519131",
 			},
 		},
+		"transform location": {
+			concept: db.Concept{
+				ID:            "http://api.ft.com/things/2b2b7d7f-6a0c-4a8f-9c0e-3f1d1c8b2d11",
+				PrefLabel:     "Lyon",
+				APIURL:        "http://api.ft.com/things/2b2b7d7f-6a0c-4a8f-9c0e-3f1d1c8b2d11",
+				BroaderUUIDs:  []string{"5a6a3c3e-2f1a-4b6e-8d1c-0c6f1f3b8e21", "b8c6c6a4-7c1e-4a44-9d3b-1e0f5d2a9c31"},
+				NarrowerUUIDs: []string{"e4d3f2a1-0b9c-4d8e-a7f6-5c4b3a2d1e41"},
+			},
+			conceptType: "Location",
+			expected: []string{
+				"http://api.ft.com/things/2b2b7d7f-6a0c-4a8f-9c0e-3f1d1c8b2d11",
+				"Lyon",
+				"http://api.ft.com/things/2b2b7d7f-6a0c-4a8f-9c0e-3f1d1c8b2d11",
+				"",
+				"",
+				"5a6a3c3e-2f1a-4b6e-8d1c-0c6f1f3b8e21;b8c6c6a4-7c1e-4a44-9d3b-1e0f5d2a9c31",
+				"e4d3f2a1-0b9c-4d8e-a7f6-5c4b3a2d1e41",
+			},
+		},
 	}
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			result := conceptToCSVRecord(test.concept, test.conceptType)
+			result := conceptToCSVRecord(test.concept, NewCsvExporter().getHeader(test.conceptType))
 			assert.Equal(t, test.expected, result)
 		})
 	}
 }
+
+func TestCsvExporter_Columns(t *testing.T) {
+	genres := new(bytes.Buffer)
+	exporter := withCSVColumns(SupportedExporters()[CSVFormat], map[string][]string{"Genre": {"prefLabel", "id"}})()
+	require.NoError(t, exporter.Prepare(map[string]io.Writer{"Genre": genres}))
+	require.NoError(t, exporter.Write(db.Concept{ID: "http://api.ft.com/things/1", PrefLabel: "Analysis"}, "Genre", "tid_1234"))
+	require.NoError(t, exporter.Flush("Genre"))
+	assert.Equal(t, "prefLabel,id\nAnalysis,http://api.ft.com/things/1\n", genres.String())
+}
+
+func TestCsvExporter_UnsupportedColumn(t *testing.T) {
+	exporter := &CsvExporter{Columns: map[string][]string{"Brand": {"id", "colour"}}}
+	err := exporter.Prepare(map[string]io.Writer{"Brand": new(bytes.Buffer)})
+	assert.EqualError(t, err, "unsupported CSV column colour of Brand")
+}
+
+func TestConceptToCSVRecord_AllColumns(t *testing.T) {
+	c := db.Concept{
+		ID:          "http://api.ft.com/things/eac853f5-3859-4c08-8540-55e043719400",
+		UUID:        "eac853f5-3859-4c08-8540-55e043719400",
+		PrefLabel:   "Fakebook",
+		Labels:      []string{"Concept", "Organisation"},
+		Aliases:     []string{"Fakebook Company"},
+		FormerNames: []string{"Facemash"},
+		TradeNames:  []string{"Fakebook Inc"},
+		ProperName:  "Fakebook, Inc.",
+		ShortName:   "Fakebook",
+		NAICSIndustryClassifications: []db.NAICSIndustryClassification{
+			{IndustryIdentifier: "519130", Rank: 1},
+			{IndustryIdentifier: "519131", Rank: 2},
+		},
+	}
+	columns := []string{"uuid", "labels", "aliases", "formerNames", "tradeNames", "properName", "shortName", "NAICS", "NAICSRank"}
+
+	assert.Equal(t, []string{
+		"eac853f5-3859-4c08-8540-55e043719400",
+		"Concept;Organisation",
+		"Fakebook Company",
+		"Facemash",
+		"Fakebook Inc",
+		"Fakebook, Inc.",
+		"Fakebook",
+		"519130;519131",
+		"1;2",
+	}, conceptToCSVRecord(c, columns))
+}
+
+func TestConceptToCSVRecord_AnnotationStats(t *testing.T) {
+	c := db.Concept{
+		UUID:        "1",
+		Annotations: &db.AnnotationStats{Mentions: 12, About: 3, HasBrand: 1, FirstAnnotated: 1453319027, LastAnnotated: 1570000000},
+	}
+
+	assert.Equal(t, []string{"1", "12", "3", "0", "0", "1", "2016-01-20T19:43:47Z", "2019-10-02T07:06:40Z"},
+		conceptToCSVRecord(c, append([]string{"uuid"}, AnnotationStatsColumns...)))
+	assert.Equal(t, []string{"1", "", ""}, conceptToCSVRecord(db.Concept{UUID: "1"}, []string{"uuid", "mentions", "firstAnnotated"}),
+		"the statistics should be empty if they weren't read")
+}
+
+func TestAppendCSVColumns(t *testing.T) {
+	columns := map[string][]string{"Brand": {"id", "hasBrand"}, "Topic": {"prefLabel"}}
+
+	assert.Equal(t, map[string][]string{
+		"Brand":        {"id", "hasBrand", "mentions", "about", "isClassifiedBy", "hasAuthor", "firstAnnotated", "lastAnnotated"},
+		"Topic":        {"prefLabel"},
+		"Organisation": append(append([]string{}, DefaultCSVColumnsByType["Organisation"]...), AnnotationStatsColumns...),
+	}, appendCSVColumns(columns, []string{"Brand", "Organisation"}, AnnotationStatsColumns...))
+	assert.Equal(t, []string{"id", "hasBrand"}, columns["Brand"], "the columns should not change")
+
+	assert.True(t, hasAnnotationStatsColumns(columns, []string{"Brand"}))
+	assert.False(t, hasAnnotationStatsColumns(columns, []string{"Topic", "Organisation"}))
+}
+
+func TestMergeCSVColumns(t *testing.T) {
+	defaults := map[string][]string{"Brand": {"id"}, "Topic": {"prefLabel"}}
+
+	assert.Equal(t, defaults, mergeCSVColumns(defaults, nil))
+	assert.Equal(t, map[string][]string{"Brand": {"uuid", "prefLabel"}, "Topic": {"prefLabel"}, "Genre": {"id"}},
+		mergeCSVColumns(defaults, map[string][]string{"Brand": {"uuid", "prefLabel"}, "Genre": {"id"}}))
+	assert.Equal(t, map[string][]string{"Brand": {"id"}, "Topic": {"prefLabel"}}, defaults, "the defaults should not change")
+}
@@ -0,0 +1,68 @@
+package export
+
+import (
+	"bufio"
+	"io"
+	"sort"
+)
+
+// dryRunSampleLines is the number of lines kept as the sample of every file of a dry run, the CSV header included
+const dryRunSampleLines = 6
+
+// DryRunFile describes a file exported by a dry run, which is not uploaded
+type DryRunFile struct {
+	Name        string `json:"Name"`
+	ConceptType string `json:"ConceptType"`
+	Change      string `json:"Change,omitempty"`
+	Rows        int    `json:"Rows"`
+	// Bytes is the size of the file as it would have been uploaded, compressed if the job compresses its files
+	Bytes int64 `json:"Bytes"`
+	// Sample has the first lines of the file, decompressed
+	Sample []string `json:"Sample,omitempty"`
+}
+
+func newDryRunFile(f file, rows int, compression string) (DryRunFile, error) {
+	dryRunFile := DryRunFile{
+		Name:        f.name,
+		ConceptType: f.conceptType,
+		Change:      f.change,
+		Rows:        rows,
+		Bytes:       f.size,
+	}
+	sample, err := sampleLines(f.content, compression, dryRunSampleLines)
+	dryRunFile.Sample = sample
+	return dryRunFile, err
+}
+
+// sampleLines returns the first lines of the content, read from its start
+func sampleLines(content io.ReadSeeker, compression string, n int) ([]string, error) {
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	r, err := decompress(content, compression)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for len(lines) < n && scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+func (fe *FullExporter) addDryRunFile(job *Job, f file, rows int, tid string) {
+	dryRunFile, err := newDryRunFile(f, rows, job.Compression)
+	if err != nil {
+		fe.Log.WithTransactionID(tid).WithError(err).Warnf("Sampling %v failed", f.name)
+	}
+	fe.Lock()
+	defer fe.Unlock()
+	job.DryRunFiles = append(job.DryRunFiles, dryRunFile)
+	sort.Slice(job.DryRunFiles, func(i, j int) bool {
+		return job.DryRunFiles[i].Name < job.DryRunFiles[j].Name
+	})
+}
@@ -0,0 +1,57 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/Financial-Times/concept-exporter/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFullExporter_DryRun(t *testing.T) {
+	updater := new(mockUpdater)
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {
+			{ID: "http://api.ft.com/things/1", UUID: "1", PrefLabel: "FT"},
+			{ID: "http://api.ft.com/things/2", UUID: "2", PrefLabel: "FT Weekend"},
+		},
+		"Topic": {{ID: "http://api.ft.com/things/3", UUID: "3", PrefLabel: "Brexit"}},
+	}}
+	fe, result := runJob(t, updater, inquirer, []string{"Topic", "Brand"}, Options{DryRun: true}, func(fe *FullExporter) {
+		fe.NrOfConcurrentWorkers = 2
+		fe.AtomicPublish = true
+	})
+
+	assert.Empty(t, result.Failed)
+	assert.Empty(t, result.Files)
+	assert.Equal(t, []DryRunFile{
+		{
+			Name: "Brand.csv", ConceptType: "Brand", Rows: 2, Bytes: 110,
+			Sample: []string{"id,prefLabel,apiUrl,alternativeLabels", "http://api.ft.com/things/1,FT,,", "http://api.ft.com/things/2,FT Weekend,,"},
+		},
+		{
+			Name: "Topic.csv", ConceptType: "Topic", Rows: 1, Bytes: 74,
+			Sample: []string{"id,prefLabel,apiUrl,alternativeLabels", "http://api.ft.com/things/3,Brexit,,"},
+		},
+	}, result.DryRunFiles)
+	updater.AssertNotCalled(t, "Upload")
+
+	_, found := fe.GetLastSuccessfulJobStart([]string{"Brand"}, Options{})
+	assert.False(t, found, "a dry run should not be the start of a delta export")
+}
+
+func TestFullExporter_DryRunSamplesCompressedFiles(t *testing.T) {
+	concepts := make([]db.Concept, 10)
+	for i := range concepts {
+		concepts[i] = db.Concept{ID: "http://api.ft.com/things/1", UUID: "1", PrefLabel: "FT"}
+	}
+	_, result := runJob(t, new(mockUpdater), &mockInquirer{concepts: map[string][]db.Concept{"Brand": concepts}}, []string{"Brand"},
+		Options{DryRun: true, Format: JSONLinesFormat, Compression: ZstdCompression})
+
+	require.Equal(t, 1, len(result.DryRunFiles))
+	f := result.DryRunFiles[0]
+	assert.Equal(t, "Brand.jsonl.zst", f.Name)
+	assert.Equal(t, 10, f.Rows)
+	assert.Equal(t, dryRunSampleLines, len(f.Sample))
+	assert.Equal(t, `{"id":"http://api.ft.com/things/1","uuid":"1","prefLabel":"FT","apiUrl":""}`, f.Sample[0])
+}
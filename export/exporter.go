@@ -0,0 +1,180 @@
+package export
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Financial-Times/concept-exporter/db"
+)
+
+// Exporter serialises concepts into one file per concept type.
+// The concepts of every type are streamed into the writer given for it to Prepare.
+type Exporter interface {
+	Prepare(writers map[string]io.Writer) error
+	Write(c db.Concept, conceptType, tid string) error
+	// Flush writes the concepts buffered by the exporter, the file of the concept type is complete afterwards
+	Flush(conceptType string) error
+	GetFileName(conceptType string) string
+	// GetColumns returns the columns of the file of the concept type, or the fields of its objects
+	GetColumns(conceptType string) []string
+}
+
+// Output formats an export can be requested in
+const (
+	CSVFormat       = "csv"
+	JSONLinesFormat = "jsonl"
+)
+
+// NewExporterFunc creates the exporter of a single job
+type NewExporterFunc func() Exporter
+
+// SupportedExporters returns the exporter constructors of every supported format
+func SupportedExporters() map[string]NewExporterFunc {
+	return map[string]NewExporterFunc{
+		CSVFormat:       func() Exporter { return NewCsvExporter() },
+		JSONLinesFormat: func() Exporter { return NewJSONLinesExporter() },
+	}
+}
+
+type file struct {
+	name        string
+	conceptType string
+	change      string
+	content     io.ReadSeeker
+	size        int64
+	sha256      string
+	// columns are the columns or fields of the records of the file
+	columns []string
+}
+
+// spoolFile is the temporary file an exported file is written to before being uploaded,
+// so that the memory used by a job doesn't depend on the number of concepts
+type spoolFile struct {
+	file *os.File
+	buf  *bufio.Writer
+	hash hash.Hash
+	size int64
+}
+
+func newSpoolFile() (*spoolFile, error) {
+	f, err := os.CreateTemp("", "concept-exporter-*")
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	return &spoolFile{file: f, buf: bufio.NewWriter(io.MultiWriter(f, h)), hash: h}, nil
+}
+
+func (s *spoolFile) Write(p []byte) (int, error) {
+	n, err := s.buf.Write(p)
+	s.size += int64(n)
+	return n, err
+}
+
+// finish flushes the file and rewinds it to be read from the start
+func (s *spoolFile) finish() error {
+	if err := s.buf.Flush(); err != nil {
+		return err
+	}
+	_, err := s.file.Seek(0, io.SeekStart)
+	return err
+}
+
+func (s *spoolFile) remove() {
+	s.file.Close()
+	os.Remove(s.file.Name())
+}
+
+// jobOutput holds the exporters of a job. A full export has a single exporter,
+// a delta export has one for each change, ending up in separate files.
+type jobOutput struct {
+	exporters map[string]Exporter
+	spools    map[string]map[string]*spoolFile
+	changes   []string
+}
+
+// newJobOutput prepares the exporters writing into a spool file for every change and concept type.
+// The spool files have to be removed with close once the files are uploaded.
+func newJobOutput(newExporter NewExporterFunc, conceptTypes []string, delta bool) (*jobOutput, error) {
+	changes := []string{""}
+	if delta {
+		changes = []string{db.Added, db.Changed, db.Removed}
+	}
+	output := &jobOutput{
+		exporters: make(map[string]Exporter, len(changes)),
+		spools:    make(map[string]map[string]*spoolFile, len(changes)),
+		changes:   changes,
+	}
+	for _, change := range changes {
+		output.spools[change] = make(map[string]*spoolFile, len(conceptTypes))
+		writers := make(map[string]io.Writer, len(conceptTypes))
+		for _, cType := range conceptTypes {
+			spool, err := newSpoolFile()
+			if err != nil {
+				output.close()
+				return nil, err
+			}
+			output.spools[change][cType] = spool
+			writers[cType] = spool
+		}
+		exporter := newExporter()
+		if err := exporter.Prepare(writers); err != nil {
+			output.close()
+			return nil, err
+		}
+		output.exporters[change] = exporter
+	}
+	return output, nil
+}
+
+func (o *jobOutput) write(c db.Concept, conceptType, tid string) error {
+	exporter, found := o.exporters[c.Change]
+	if !found {
+		return fmt.Errorf("unexpected change %q of concept %v", c.Change, c.UUID)
+	}
+	return exporter.Write(c, conceptType, tid)
+}
+
+// files completes the files of the concept type, for a delta export named like Brand-added.csv
+func (o *jobOutput) files(conceptType string) ([]file, error) {
+	var files []file
+	for _, change := range o.changes {
+		exporter := o.exporters[change]
+		spool := o.spools[change][conceptType]
+		if err := exporter.Flush(conceptType); err != nil {
+			return nil, err
+		}
+		if err := spool.finish(); err != nil {
+			return nil, err
+		}
+		name := exporter.GetFileName(conceptType)
+		if change != "" {
+			name = conceptType + "-" + change + strings.TrimPrefix(name, conceptType)
+		}
+		files = append(files, file{
+			name:        name,
+			conceptType: conceptType,
+			change:      change,
+			content:     spool.file,
+			size:        spool.size,
+			sha256:      hex.EncodeToString(spool.hash.Sum(nil)),
+			columns:     exporter.GetColumns(conceptType),
+		})
+	}
+	return files, nil
+}
+
+// close removes the spool files
+func (o *jobOutput) close() {
+	for _, spools := range o.spools {
+		for _, spool := range spools {
+			spool.remove()
+		}
+	}
+}
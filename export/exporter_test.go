@@ -0,0 +1,38 @@
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/Financial-Times/concept-exporter/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobOutput_SpoolsFiles(t *testing.T) {
+	output, err := newJobOutput(SupportedExporters()[CSVFormat], []string{"Brand", "Topic"}, false)
+	require.NoError(t, err)
+
+	require.NoError(t, output.write(db.Concept{ID: "http://api.ft.com/things/1", PrefLabel: "FT"}, "Brand", "tid_1234"))
+	files, err := output.files("Brand")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(files))
+
+	f := files[0]
+	content, err := io.ReadAll(f.content)
+	require.NoError(t, err)
+	sum := sha256.Sum256(content)
+	expected := "id,prefLabel,apiUrl,alternativeLabels\nhttp://api.ft.com/things/1,FT,,\n"
+	assert.Equal(t, expected, string(content))
+	assert.Equal(t, "Brand.csv", f.name)
+	assert.Equal(t, int64(len(expected)), f.size)
+	assert.Equal(t, hex.EncodeToString(sum[:]), f.sha256)
+
+	spool := output.spools[""]["Topic"].file.Name()
+	output.close()
+	_, err = os.Stat(spool)
+	assert.True(t, os.IsNotExist(err), "spool file left behind")
+}
@@ -0,0 +1,131 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/Financial-Times/concept-exporter/db"
+)
+
+// JSONLinesExporter writes every concept as a JSON object on its own line.
+// Unlike the CSV it keeps the alternative labels separated by their origin and the NAICS ranks.
+type JSONLinesExporter struct {
+	Writer map[string]*json.Encoder
+}
+
+type jsonConcept struct {
+	ID                           string                           `json:"id"`
+	UUID                         string                           `json:"uuid"`
+	PrefLabel                    string                           `json:"prefLabel"`
+	APIURL                       string                           `json:"apiUrl"`
+	Labels                       []string                         `json:"labels,omitempty"`
+	AlternativeLabels            []string                         `json:"alternativeLabels,omitempty"`
+	Aliases                      []string                         `json:"aliases,omitempty"`
+	FormerNames                  []string                         `json:"formerNames,omitempty"`
+	TradeNames                   []string                         `json:"tradeNames,omitempty"`
+	ProperName                   string                           `json:"properName,omitempty"`
+	ShortName                    string                           `json:"shortName,omitempty"`
+	LeiCode                      string                           `json:"leiCode,omitempty"`
+	FactsetIDs                   []string                         `json:"factsetIds,omitempty"`
+	FigiCodes                    []string                         `json:"figiCodes,omitempty"`
+	NAICSIndustryClassifications []db.NAICSIndustryClassification `json:"naicsIndustryClassifications,omitempty"`
+	ISO31661                     string                           `json:"iso31661,omitempty"`
+	BroaderUUIDs                 []string                         `json:"broaderUuids,omitempty"`
+	NarrowerUUIDs                []string                         `json:"narrowerUuids,omitempty"`
+	Annotations                  *jsonAnnotationStats             `json:"annotations,omitempty"`
+	Annotated                    *bool                            `json:"annotated,omitempty"`
+}
+
+type jsonAnnotationStats struct {
+	Mentions       int    `json:"mentions"`
+	About          int    `json:"about"`
+	IsClassifiedBy int    `json:"isClassifiedBy"`
+	HasAuthor      int    `json:"hasAuthor"`
+	HasBrand       int    `json:"hasBrand"`
+	FirstAnnotated string `json:"firstAnnotated,omitempty"`
+	LastAnnotated  string `json:"lastAnnotated,omitempty"`
+}
+
+func NewJSONLinesExporter() *JSONLinesExporter {
+	return &JSONLinesExporter{}
+}
+
+// Flush does nothing, every concept is written to the writer of its type right away
+func (e *JSONLinesExporter) Flush(conceptType string) error {
+	return nil
+}
+
+func (e *JSONLinesExporter) Prepare(writers map[string]io.Writer) error {
+	writer := make(map[string]*json.Encoder, len(writers))
+	for cType, w := range writers {
+		writer[cType] = json.NewEncoder(w)
+	}
+	e.Writer = writer
+	return nil
+}
+
+func (e *JSONLinesExporter) Write(c db.Concept, conceptType, tid string) error {
+	// json.Encoder terminates every value with a newline
+	return e.Writer[conceptType].Encode(conceptToJSON(c))
+}
+
+func (e *JSONLinesExporter) GetFileName(conceptType string) string {
+	return conceptType + ".jsonl"
+}
+
+// GetColumns returns the fields a concept can have, those without a value are left out of its object
+func (e *JSONLinesExporter) GetColumns(conceptType string) []string {
+	return jsonConceptFields
+}
+
+// jsonConceptFields are the names of the fields of jsonConcept
+var jsonConceptFields = jsonFields(reflect.TypeOf(jsonConcept{}))
+
+// jsonFields returns the names the fields of the struct type are encoded under
+func jsonFields(t reflect.Type) []string {
+	fields := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+func conceptToJSON(c db.Concept) jsonConcept {
+	var annotations *jsonAnnotationStats
+	if a := c.Annotations; a != nil {
+		annotations = &jsonAnnotationStats{
+			Mentions:       a.Mentions,
+			About:          a.About,
+			IsClassifiedBy: a.IsClassifiedBy,
+			HasAuthor:      a.HasAuthor,
+			HasBrand:       a.HasBrand,
+			FirstAnnotated: formatEpoch(a.FirstAnnotated),
+			LastAnnotated:  formatEpoch(a.LastAnnotated),
+		}
+	}
+	return jsonConcept{
+		ID:                           c.ID,
+		UUID:                         c.UUID,
+		PrefLabel:                    c.PrefLabel,
+		APIURL:                       c.APIURL,
+		Labels:                       c.Labels,
+		AlternativeLabels:            c.AlternativeLabels,
+		Aliases:                      c.Aliases,
+		FormerNames:                  c.FormerNames,
+		TradeNames:                   c.TradeNames,
+		ProperName:                   c.ProperName,
+		ShortName:                    c.ShortName,
+		LeiCode:                      c.LeiCode,
+		FactsetIDs:                   c.FactsetIDs,
+		FigiCodes:                    c.FigiCodes,
+		NAICSIndustryClassifications: c.NAICSIndustryClassifications,
+		ISO31661:                     c.ISO31661,
+		BroaderUUIDs:                 c.BroaderUUIDs,
+		NarrowerUUIDs:                c.NarrowerUUIDs,
+		Annotations:                  annotations,
+		Annotated:                    c.Annotated,
+	}
+}
@@ -0,0 +1,92 @@
+package export
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/Financial-Times/concept-exporter/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONLinesExporter_AnnotationStats(t *testing.T) {
+	out := new(bytes.Buffer)
+	exporter := NewJSONLinesExporter()
+	assert.NoError(t, exporter.Prepare(map[string]io.Writer{"Topic": out}))
+
+	assert.NoError(t, exporter.Write(db.Concept{
+		UUID:        "1",
+		Annotations: &db.AnnotationStats{Mentions: 2, FirstAnnotated: 1453319027, LastAnnotated: 1453319027},
+	}, "Topic", "tid_1234"))
+	assert.Equal(t,
+		`{"id":"","uuid":"1","prefLabel":"","apiUrl":"","annotations":{"mentions":2,"about":0,"isClassifiedBy":0,"hasAuthor":0,"hasBrand":0,`+
+			`"firstAnnotated":"2016-01-20T19:43:47Z","lastAnnotated":"2016-01-20T19:43:47Z"}}`+"\n",
+		out.String())
+}
+
+func TestJSONLinesExporter_Location(t *testing.T) {
+	locations := new(bytes.Buffer)
+	exporter := NewJSONLinesExporter()
+	assert.NoError(t, exporter.Prepare(map[string]io.Writer{"Location": locations}))
+
+	assert.NoError(t, exporter.Write(db.Concept{
+		ID:            "http://api.ft.com/things/5a6a3c3e-2f1a-4b6e-8d1c-0c6f1f3b8e21",
+		UUID:          "5a6a3c3e-2f1a-4b6e-8d1c-0c6f1f3b8e21",
+		PrefLabel:     "France",
+		APIURL:        "http://api.ft.com/things/5a6a3c3e-2f1a-4b6e-8d1c-0c6f1f3b8e21",
+		ISO31661:      "FR",
+		BroaderUUIDs:  []string{"b8c6c6a4-7c1e-4a44-9d3b-1e0f5d2a9c31"},
+		NarrowerUUIDs: []string{"2b2b7d7f-6a0c-4a8f-9c0e-3f1d1c8b2d11"},
+	}, "Location", "tid_1234"))
+
+	assert.NoError(t, exporter.Flush("Location"))
+	assert.Equal(t,
+		`{"id":"http://api.ft.com/things/5a6a3c3e-2f1a-4b6e-8d1c-0c6f1f3b8e21","uuid":"5a6a3c3e-2f1a-4b6e-8d1c-0c6f1f3b8e21","prefLabel":"France","apiUrl":"http://api.ft.com/things/5a6a3c3e-2f1a-4b6e-8d1c-0c6f1f3b8e21",`+
+			`"iso31661":"FR","broaderUuids":["b8c6c6a4-7c1e-4a44-9d3b-1e0f5d2a9c31"],"narrowerUuids":["2b2b7d7f-6a0c-4a8f-9c0e-3f1d1c8b2d11"]}`+"\n",
+		locations.String())
+}
+
+func TestJSONLinesExporter(t *testing.T) {
+	brands, organisations := new(bytes.Buffer), new(bytes.Buffer)
+	exporter := NewJSONLinesExporter()
+	assert.NoError(t, exporter.Prepare(map[string]io.Writer{"Brand": brands, "Organisation": organisations}))
+
+	assert.NoError(t, exporter.Write(db.Concept{
+		ID:        "http://api.ft.com/things/dbb0bdae-1f0c-1a1a-b0cb-b2227cce2b54",
+		UUID:      "dbb0bdae-1f0c-1a1a-b0cb-b2227cce2b54",
+		PrefLabel: "Financial Times",
+		APIURL:    "http://api.ft.com/brands/dbb0bdae-1f0c-1a1a-b0cb-b2227cce2b54",
+	}, "Brand", "tid_1234"))
+	assert.NoError(t, exporter.Write(db.Concept{
+		ID:                "http://api.ft.com/things/eac853f5-3859-4c08-8540-55e043719400",
+		UUID:              "eac853f5-3859-4c08-8540-55e043719400",
+		PrefLabel:         "Fakebook",
+		APIURL:            "http://api.ft.com/organisations/eac853f5-3859-4c08-8540-55e043719400",
+		AlternativeLabels: []string{"Fakebook Company", "Fakebook Inc"},
+		Aliases:           []string{"Fakebook Company"},
+		TradeNames:        []string{"Fakebook Inc"},
+		LeiCode:           "PBLD0EJDB5FWOLXP3B76",
+		NAICSIndustryClassifications: []db.NAICSIndustryClassification{
+			{IndustryIdentifier: "519130", Rank: 1},
+		},
+	}, "Organisation", "tid_1234"))
+
+	assert.NoError(t, exporter.Flush("Brand"))
+	assert.Equal(t, "Brand.jsonl", exporter.GetFileName("Brand"))
+	assert.Equal(t,
+		`{"id":"http://api.ft.com/things/dbb0bdae-1f0c-1a1a-b0cb-b2227cce2b54","uuid":"dbb0bdae-1f0c-1a1a-b0cb-b2227cce2b54","prefLabel":"Financial Times","apiUrl":"http://api.ft.com/brands/dbb0bdae-1f0c-1a1a-b0cb-b2227cce2b54"}`+"\n",
+		brands.String())
+	assert.Equal(t,
+		`{"id":"http://api.ft.com/things/eac853f5-3859-4c08-8540-55e043719400","uuid":"eac853f5-3859-4c08-8540-55e043719400","prefLabel":"Fakebook","apiUrl":"http://api.ft.com/organisations/eac853f5-3859-4c08-8540-55e043719400",`+
+			`"alternativeLabels":["Fakebook Company","Fakebook Inc"],"aliases":["Fakebook Company"],"tradeNames":["Fakebook Inc"],"leiCode":"PBLD0EJDB5FWOLXP3B76","naicsIndustryClassifications":[{"id":"519130","rank":1}]}`+"\n",
+		organisations.String())
+}
+
+func TestJSONLinesExporter_GetColumns(t *testing.T) {
+	columns := NewJSONLinesExporter().GetColumns("Brand")
+	assert.Equal(t, "id", columns[0])
+	assert.Contains(t, columns, "annotations")
+	assert.Contains(t, columns, "annotated")
+	assert.Len(t, columns, reflect.TypeOf(jsonConcept{}).NumField())
+}
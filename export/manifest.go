@@ -0,0 +1,79 @@
+package export
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// ManifestFileName is the file uploaded after all the other files of a job,
+// consumers can take its presence as the sign that the export is complete
+const ManifestFileName = "manifest.json"
+
+// ManifestVersion is the version of the format of the manifest, it changes whenever its fields do.
+// The layout of the exported files is described by the columns of every file instead.
+const ManifestVersion = 1
+
+// Manifest describes the files uploaded by a job
+type Manifest struct {
+	JobID              string         `json:"jobId"`
+	Format             string         `json:"format"`
+	Compression        string         `json:"compression"`
+	ManifestVersion    int            `json:"manifestVersion"`
+	Since              *time.Time     `json:"since,omitempty"`
+	StartTime          *time.Time     `json:"startTime,omitempty"`
+	CreatedTime        time.Time      `json:"createdTime"`
+	FailedConceptTypes []string       `json:"failedConceptTypes,omitempty"`
+	Files              []ManifestFile `json:"files"`
+}
+
+// ManifestFile describes a single uploaded file
+type ManifestFile struct {
+	Name        string `json:"name"`
+	JobID       string `json:"jobId"`
+	ConceptType string `json:"conceptType"`
+	Change      string `json:"change,omitempty"`
+	Rows        int    `json:"rows"`
+	Bytes       int64  `json:"bytes"`
+	SHA256      string `json:"sha256"`
+	Format      string `json:"format"`
+	Compression string `json:"compression"`
+	// Columns are the CSV columns of the file, or the fields its JSON objects can have
+	Columns      []string  `json:"columns"`
+	UploadedTime time.Time `json:"uploadedTime"`
+}
+
+func newManifestFile(job *Job, f file, rows int) ManifestFile {
+	return ManifestFile{
+		Name:         f.name,
+		JobID:        job.ID,
+		ConceptType:  f.conceptType,
+		Change:       f.change,
+		Rows:         rows,
+		Bytes:        f.size,
+		SHA256:       f.sha256,
+		Format:       job.Format,
+		Compression:  job.Compression,
+		Columns:      f.columns,
+		UploadedTime: time.Now().UTC(),
+	}
+}
+
+// newManifest returns the manifest of the files uploaded by the job so far, sorted by name
+func newManifest(job *Job) ([]byte, error) {
+	files := append([]ManifestFile{}, job.manifest...)
+	sort.Slice(files, func(k, l int) bool {
+		return files[k].Name < files[l].Name
+	})
+	return json.MarshalIndent(Manifest{
+		JobID:              job.ID,
+		Format:             job.Format,
+		Compression:        job.Compression,
+		ManifestVersion:    ManifestVersion,
+		Since:              job.Since,
+		StartTime:          job.StartTime,
+		CreatedTime:        time.Now().UTC(),
+		FailedConceptTypes: job.Failed,
+		Files:              files,
+	}, "", "  ")
+}
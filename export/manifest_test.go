@@ -0,0 +1,140 @@
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/concept-exporter/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFullExporter_RunExportUploadsManifestLast(t *testing.T) {
+	var mu sync.Mutex
+	uploads := map[string][]byte{}
+	var order []string
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, mock.Anything, mock.Anything, "tid_1234").Return(nil).Run(func(args mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		uploads[args.String(2)] = args.Get(1).([]byte)
+		order = append(order, args.String(2))
+	})
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {
+			{ID: "http://api.ft.com/things/1", UUID: "1", PrefLabel: "FT", Change: db.Added},
+			{ID: "http://api.ft.com/things/2", UUID: "2", PrefLabel: "FT Weekend", Change: db.Added},
+			{ID: "http://api.ft.com/things/3", UUID: "3", PrefLabel: "FT Alphaville", Change: db.Removed},
+		},
+		"Topic": {
+			{ID: "http://api.ft.com/things/4", UUID: "4", PrefLabel: "Brexit", Change: db.Changed},
+		},
+	}}
+	since := time.Date(2019, 10, 1, 2, 0, 0, 0, time.UTC)
+	_, job := runJob(t, updater, inquirer, []string{"Brand", "Topic"}, Options{Since: &since}, func(fe *FullExporter) {
+		fe.NrOfConcurrentWorkers = 2
+		addBaselineJob(t, fe, since, Options{}, map[string][]string{"Brand": {"3"}, "Topic": {"4"}})
+	})
+
+	require.Equal(t, 7, len(order))
+	assert.Equal(t, ManifestFileName, order[6], "the manifest should be uploaded last")
+
+	var manifest Manifest
+	require.NoError(t, json.Unmarshal(uploads[ManifestFileName], &manifest))
+	assert.Equal(t, job.ID, manifest.JobID)
+	assert.Equal(t, CSVFormat, manifest.Format)
+	assert.Equal(t, NoCompression, manifest.Compression)
+	assert.Equal(t, ManifestVersion, manifest.ManifestVersion)
+	assert.Equal(t, &since, manifest.Since)
+	assert.NotNil(t, manifest.StartTime)
+	assert.Empty(t, manifest.FailedConceptTypes)
+
+	rows := map[string]int{
+		"Brand-added.csv":   2,
+		"Brand-changed.csv": 0,
+		"Brand-removed.csv": 1,
+		"Topic-added.csv":   0,
+		"Topic-changed.csv": 1,
+		"Topic-removed.csv": 0,
+	}
+	require.Equal(t, len(rows), len(manifest.Files))
+	for i, f := range manifest.Files {
+		if i > 0 {
+			assert.Less(t, manifest.Files[i-1].Name, f.Name, "the files should be sorted by name")
+		}
+		content := uploads[f.Name]
+		sum := sha256.Sum256(content)
+		assert.Equal(t, rows[f.Name], f.Rows, f.Name)
+		assert.Equal(t, int64(len(content)), f.Bytes, f.Name)
+		assert.Equal(t, hex.EncodeToString(sum[:]), f.SHA256, f.Name)
+		assert.Equal(t, job.ID, f.JobID)
+		assert.Equal(t, CSVFormat, f.Format)
+		assert.Equal(t, DefaultCSVColumns, f.Columns)
+		assert.False(t, f.UploadedTime.IsZero())
+	}
+	assert.Equal(t, "Brand", manifest.Files[0].ConceptType)
+	assert.Equal(t, db.Added, manifest.Files[0].Change)
+}
+
+func TestFullExporter_NoManifestWithoutFiles(t *testing.T) {
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, mock.Anything, "Brand.csv", "tid_1234").Return(assert.AnError)
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {{ID: "http://api.ft.com/things/1", PrefLabel: "FT"}},
+	}}
+	_, result := runJob(t, updater, inquirer, []string{"Brand"}, Options{})
+
+	assert.Equal(t, []string{"Brand"}, result.Failed)
+	assert.Empty(t, result.Files)
+	updater.AssertNotCalled(t, "Upload", mock.Anything, mock.Anything, ManifestFileName, mock.Anything)
+}
+
+func TestFullExporter_FailedManifestUploadIsNotSuccessful(t *testing.T) {
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, mock.Anything, "Brand.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, ManifestFileName, "tid_1234").Return(assert.AnError)
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {{ID: "http://api.ft.com/things/1", PrefLabel: "FT"}},
+	}}
+	fe, result := runJob(t, updater, inquirer, []string{"Brand"}, Options{})
+
+	assert.Empty(t, result.Failed)
+	assert.False(t, result.Succeeded)
+	assert.Contains(t, result.ErrorMessage, "manifest upload failed")
+	_, found := fe.GetLastSuccessfulJobStart([]string{"Brand"}, Options{})
+	assert.False(t, found)
+}
+
+func TestFullExporter_ManifestHasColumnsOfEveryFile(t *testing.T) {
+	uploads := map[string][]byte{}
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, mock.Anything, mock.Anything, "tid_1234").Return(nil).Run(func(args mock.Arguments) {
+		uploads[args.String(2)] = args.Get(1).([]byte)
+	})
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {{ID: "http://api.ft.com/things/1", UUID: "1", PrefLabel: "FT"}},
+		"Topic": {{ID: "http://api.ft.com/things/2", UUID: "2", PrefLabel: "Brexit"}},
+	}}
+	withBrandColumns := func(fe *FullExporter) {
+		fe.CSVColumns = map[string][]string{"Brand": {"uuid", "prefLabel"}}
+	}
+	runJob(t, updater, inquirer, []string{"Brand", "Topic"}, Options{}, withBrandColumns)
+
+	var manifest Manifest
+	require.NoError(t, json.Unmarshal(uploads[ManifestFileName], &manifest))
+	require.Len(t, manifest.Files, 2)
+	assert.Equal(t, []string{"uuid", "prefLabel"}, manifest.Files[0].Columns, "the overridden columns should be listed")
+	assert.Equal(t, DefaultCSVColumns, manifest.Files[1].Columns)
+
+	runJob(t, updater, inquirer, []string{"Brand"}, Options{Format: JSONLinesFormat}, withBrandColumns)
+
+	require.NoError(t, json.Unmarshal(uploads[ManifestFileName], &manifest))
+	require.Len(t, manifest.Files, 1)
+	assert.Equal(t, jsonConceptFields, manifest.Files[0].Columns)
+	assert.Contains(t, manifest.Files[0].Columns, "naicsIndustryClassifications")
+}
@@ -0,0 +1,195 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Financial-Times/concept-exporter/db"
+)
+
+// recordFile is a file of a job made of the records read for all its concept types rather than of concepts,
+// like RelationshipsFile. Its name is reported in place of a concept type.
+type recordFile struct {
+	name    string
+	columns []string
+	// read reads the records of the concept type, passing every one of them to write
+	read func(ctx context.Context, conceptType string, write func(record []string) error) error
+}
+
+// recordEncoder writes records in the format of a job
+type recordEncoder interface {
+	encode(record []string) error
+	flush() error
+}
+
+type csvRecordEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvRecordEncoder) encode(record []string) error {
+	return e.w.Write(record)
+}
+
+func (e *csvRecordEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonRecordEncoder writes every record as a JSON object on its own line, with the columns as keys in their order
+type jsonRecordEncoder struct {
+	w       io.Writer
+	columns []string
+}
+
+func (e *jsonRecordEncoder) encode(record []string) error {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, column := range e.columns {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(column)
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(record[i])
+		if err != nil {
+			return err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteString("}\n")
+	_, err := e.w.Write(b.Bytes())
+	return err
+}
+
+func (e *jsonRecordEncoder) flush() error {
+	return nil
+}
+
+// newRecordEncoder returns the encoder of the record file and its name without the compression extension.
+// The records are written as JSON lines for a JSON lines job and as CSV with a header otherwise.
+func newRecordEncoder(rf recordFile, format string, w io.Writer) (recordEncoder, string, error) {
+	if format == JSONLinesFormat {
+		return &jsonRecordEncoder{w: w, columns: rf.columns}, rf.name + ".jsonl", nil
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(rf.columns); err != nil {
+		return nil, "", err
+	}
+	return &csvRecordEncoder{w: cw}, rf.name + ".csv", nil
+}
+
+// readChannel runs a read sending its values on a channel it closes, passing every value to write.
+// The values are drained after a failed write for the read to end.
+func readChannel[T any](ctx context.Context, conceptType string, read func(context.Context, string, chan T) (int, error), write func(T) error) error {
+	ch := make(chan T)
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := read(ctx, conceptType, ch)
+		errCh <- err
+	}()
+	var writeErr error
+	for v := range ch {
+		if writeErr == nil {
+			writeErr = write(v)
+		}
+	}
+	if err := <-errCh; err != nil {
+		return err
+	}
+	return writeErr
+}
+
+// recordFiles returns the record files the job asks for, about the concepts read with the given options
+func (fe *FullExporter) recordFiles(job *Job, opts db.ReadOptions) []recordFile {
+	var files []recordFile
+	if job.Relationships {
+		files = append(files, fe.relationshipsFile(opts))
+	}
+	if job.Concordance {
+		files = append(files, fe.concordanceFile(opts))
+	}
+	return files
+}
+
+// exportRecords writes the records of the exported concept types into a single file,
+// which is uploaded or described like the files of the concept types.
+// The name of the record file is added to the failed concept types of the job if it can't be completed.
+func (fe *FullExporter) exportRecords(ctx context.Context, job *Job, rf recordFile, tid string) {
+	logEntry := fe.Log.WithTransactionID(tid)
+	fail := func(err error) {
+		if ctx.Err() != nil {
+			return
+		}
+		logEntry.WithError(err).Errorf("Exporting the %v of job %v failed", rf.name, job.ID)
+		fe.setJobFailed(job, rf.name)
+		fe.setJobErrorMessage(job, fmt.Sprintf("%s %s: %s", job.ErrorMessage, rf.name, err.Error()))
+	}
+
+	spool, err := newSpoolFile()
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer spool.remove()
+	compressed, err := compressWriter(spool, job.Compression)
+	if err != nil {
+		fail(err)
+		return
+	}
+	encoder, name, err := newRecordEncoder(rf, job.Format, compressed)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	rows := 0
+	write := func(record []string) error {
+		rows++
+		return encoder.encode(record)
+	}
+	for _, cType := range job.Concepts {
+		if err := rf.read(ctx, cType, write); err != nil {
+			fail(err)
+			return
+		}
+	}
+	if err := encoder.flush(); err != nil {
+		fail(err)
+		return
+	}
+	if err := compressed.Close(); err != nil {
+		fail(err)
+		return
+	}
+	if err := spool.finish(); err != nil {
+		fail(err)
+		return
+	}
+
+	f := file{
+		name:        fe.uploadName(job, name+compressionExtensions[job.Compression]),
+		conceptType: rf.name,
+		content:     spool.file,
+		size:        spool.size,
+		sha256:      hex.EncodeToString(spool.hash.Sum(nil)),
+		columns:     rf.columns,
+	}
+	if job.DryRun {
+		fe.addDryRunFile(job, f, rows, tid)
+		return
+	}
+	if err := fe.Updater.Upload(ctx, f.content, f.name, tid); err != nil {
+		fail(err)
+		return
+	}
+	fe.addJobFile(job, f, rows)
+}
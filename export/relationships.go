@@ -0,0 +1,33 @@
+package export
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Financial-Times/concept-exporter/db"
+)
+
+// RelationshipsFile is the name the relationships file of a job is reported under, in place of a concept type
+const RelationshipsFile = "relationships"
+
+// relationshipsColumns are the columns of the relationships file
+var relationshipsColumns = []string{"sourceId", "predicate", "targetId"}
+
+// relationshipsFile returns the file of the relationships of the concepts read with the given options
+func (fe *FullExporter) relationshipsFile(opts db.ReadOptions) recordFile {
+	return recordFile{
+		name:    RelationshipsFile,
+		columns: relationshipsColumns,
+		read: func(ctx context.Context, conceptType string, write func(record []string) error) error {
+			if fe.Relationships == nil {
+				return errors.New("relationships can't be read")
+			}
+			read := func(ctx context.Context, conceptType string, relCh chan db.Relationship) (int, error) {
+				return fe.Relationships.ReadRelationships(ctx, conceptType, opts, relCh)
+			}
+			return readChannel(ctx, conceptType, read, func(rel db.Relationship) error {
+				return write([]string{rel.SourceID, rel.Predicate, rel.TargetID})
+			})
+		},
+	}
+}
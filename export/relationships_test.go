@@ -0,0 +1,82 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Financial-Times/concept-exporter/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockRelationshipService struct {
+	relationships map[string][]db.Relationship
+	err           error
+}
+
+func (m *mockRelationshipService) ReadRelationships(ctx context.Context, conceptType string, opts db.ReadOptions, relCh chan db.Relationship) (int, error) {
+	defer close(relCh)
+	for _, rel := range m.relationships[conceptType] {
+		relCh <- rel
+	}
+	return len(m.relationships[conceptType]), m.err
+}
+
+var testRelationships = map[string][]db.Relationship{
+	"Brand": {{SourceID: "http://api.ft.com/things/1", Predicate: "HAS_PARENT", TargetID: "http://api.ft.com/things/2"}},
+	"Topic": {{SourceID: "http://api.ft.com/things/3", Predicate: "IS_RELATED_TO", TargetID: "http://api.ft.com/things/4"}},
+}
+
+func TestFullExporter_RunFullExportWithRelationships(t *testing.T) {
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, mock.Anything, "Brand.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, "Topic.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, []byte("sourceId,predicate,targetId\n"+
+		"http://api.ft.com/things/1,HAS_PARENT,http://api.ft.com/things/2\n"+
+		"http://api.ft.com/things/3,IS_RELATED_TO,http://api.ft.com/things/4\n"), "relationships.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, ManifestFileName, "tid_1234").Return(nil)
+	_, result := runJob(t, updater, &mockInquirer{}, []string{"Brand", "Topic"}, Options{Relationships: true}, func(fe *FullExporter) {
+		fe.Relationships = &mockRelationshipService{relationships: testRelationships}
+	})
+
+	assert.Empty(t, result.Failed)
+	assert.Contains(t, result.Files, "relationships.csv")
+	updater.AssertExpectations(t)
+}
+
+func TestFullExporter_RunFullExportWithRelationshipsAsJSONLines(t *testing.T) {
+	options := Options{Relationships: true, Format: JSONLinesFormat, Compression: GzipCompression, DryRun: true}
+	_, result := runJob(t, new(mockUpdater), &mockInquirer{}, []string{"Brand"}, options, func(fe *FullExporter) {
+		fe.Relationships = &mockRelationshipService{relationships: testRelationships}
+	})
+
+	assert.Len(t, result.DryRunFiles, 2)
+	rels := result.DryRunFiles[1]
+	assert.Equal(t, "relationships.jsonl.gz", rels.Name)
+	assert.Equal(t, RelationshipsFile, rels.ConceptType)
+	assert.Equal(t, 1, rels.Rows)
+	assert.Equal(t, []string{`{"sourceId":"http://api.ft.com/things/1","predicate":"HAS_PARENT","targetId":"http://api.ft.com/things/2"}`}, rels.Sample)
+}
+
+func TestFullExporter_RunFullExportWithRelationshipsFailure(t *testing.T) {
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, mock.Anything, "Brand.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, ManifestFileName, "tid_1234").Return(nil)
+	_, result := runJob(t, updater, &mockInquirer{}, []string{"Brand"}, Options{Relationships: true}, func(fe *FullExporter) {
+		fe.Relationships = &mockRelationshipService{err: errors.New("neo is down")}
+	})
+
+	assert.Equal(t, []string{RelationshipsFile}, result.Failed)
+	assert.Contains(t, result.ErrorMessage, "relationships: neo is down")
+	assert.Equal(t, []string{"Brand.csv", ManifestFileName}, result.Files)
+	updater.AssertExpectations(t)
+}
+
+func TestFullExporter_RunFullExportWithoutRelationshipService(t *testing.T) {
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, mock.Anything, mock.Anything, "tid_1234").Return(nil)
+	_, result := runJob(t, updater, &mockInquirer{}, []string{"Brand"}, Options{Relationships: true})
+
+	assert.Equal(t, []string{RelationshipsFile}, result.Failed)
+}
@@ -0,0 +1,146 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	logger "github.com/Financial-Times/go-logger/v2"
+	transactionidutils "github.com/Financial-Times/transactionid-utils-go"
+	"github.com/robfig/cron/v3"
+)
+
+// ScheduleEntry is a cron expression with the concept types exported when it fires.
+// An entry without concept types exports every supported one.
+type ScheduleEntry struct {
+	Spec         string
+	ConceptTypes []string
+	schedule     cron.Schedule
+}
+
+// ScheduledRun is the next planned export of a schedule entry
+type ScheduledRun struct {
+	Schedule string    `json:"Schedule"`
+	Concepts []string  `json:"Concepts"`
+	Time     time.Time `json:"Time"`
+}
+
+// ParseSchedule parses the entries of a schedule separated by semicolons.
+// Every entry is a standard 5 field cron expression, optionally followed by a pipe and the comma separated concept types to export,
+// e.g. "0 2 * * *|Brand,Topic;0 3 * * 0|Organisation".
+func ParseSchedule(schedule string, supportedTypes []string) ([]ScheduleEntry, error) {
+	var entries []ScheduleEntry
+	for _, e := range strings.Split(schedule, ";") {
+		if strings.TrimSpace(e) == "" {
+			continue
+		}
+		spec, types, _ := strings.Cut(e, "|")
+		spec = strings.TrimSpace(spec)
+		s, err := cron.ParseStandard(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		entry := ScheduleEntry{Spec: spec, ConceptTypes: supportedTypes, schedule: s}
+		if strings.TrimSpace(types) != "" {
+			entry.ConceptTypes = nil
+			for _, cType := range strings.Split(types, ",") {
+				cType = strings.TrimSpace(cType)
+				if !containsAll(supportedTypes, []string{cType}) {
+					return nil, fmt.Errorf("unsupported concept type %q in schedule %q", cType, spec)
+				}
+				entry.ConceptTypes = append(entry.ConceptTypes, cType)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("empty schedule")
+	}
+	return entries, nil
+}
+
+// Scheduler starts the exports of its entries at the planned times.
+// A planned export is skipped if another job is still running at that time.
+type Scheduler struct {
+	sync.Mutex
+	Exporter *FullExporter
+	Entries  []ScheduleEntry
+	Log      *logger.UPPLogger
+	cron     *cron.Cron
+	ids      []cron.EntryID
+}
+
+func NewScheduler(fullExporter *FullExporter, entries []ScheduleEntry, log *logger.UPPLogger) *Scheduler {
+	return &Scheduler{
+		Exporter: fullExporter,
+		Entries:  entries,
+		Log:      log,
+	}
+}
+
+// Start plans the exports of the entries, the times of the cron expressions are in UTC
+func (s *Scheduler) Start() {
+	s.Lock()
+	defer s.Unlock()
+	s.cron = cron.New(cron.WithLocation(time.UTC))
+	s.ids = nil
+	for _, entry := range s.Entries {
+		e := entry
+		s.ids = append(s.ids, s.cron.Schedule(e.schedule, cron.FuncJob(func() { s.run(e) })))
+	}
+	s.cron.Start()
+	for _, run := range s.nextRuns() {
+		s.Log.Infof("Export of %v scheduled with %q, next run at %v", run.Concepts, run.Schedule, run.Time)
+	}
+}
+
+// Stop stops planning exports, it doesn't stop a running one
+func (s *Scheduler) Stop() {
+	s.Lock()
+	defer s.Unlock()
+	if s.cron != nil {
+		s.cron.Stop()
+	}
+}
+
+// NextRuns returns the next planned export of every entry, the earliest one first
+func (s *Scheduler) NextRuns() []ScheduledRun {
+	s.Lock()
+	defer s.Unlock()
+	return s.nextRuns()
+}
+
+func (s *Scheduler) nextRuns() []ScheduledRun {
+	var runs []ScheduledRun
+	if s.cron == nil {
+		return runs
+	}
+	for i, id := range s.ids {
+		entry := s.Entries[i]
+		next := s.cron.Entry(id).Next
+		if next.IsZero() {
+			// the cron runner plans the first runs asynchronously after being started
+			next = entry.schedule.Next(time.Now().UTC())
+		}
+		runs = append(runs, ScheduledRun{Schedule: entry.Spec, Concepts: entry.ConceptTypes, Time: next})
+	}
+	sort.SliceStable(runs, func(k, l int) bool {
+		return runs[k].Time.Before(runs[l].Time)
+	})
+	return runs
+}
+
+func (s *Scheduler) run(entry ScheduleEntry) {
+	tid := transactionidutils.NewTransactionID()
+	logEntry := s.Log.WithTransactionID(tid)
+	job, err := s.Exporter.TryCreateJob(entry.ConceptTypes, Options{}, "")
+	if err != nil {
+		logEntry.Warnf("Skipping scheduled export of %v with %q, there is already a running export job", entry.ConceptTypes, entry.Spec)
+		return
+	}
+	logEntry.Infof("Scheduled export of %v with %q created job %v", entry.ConceptTypes, entry.Spec, job.ID)
+	s.Exporter.RunFullExport(job, tid)
+}
@@ -0,0 +1,92 @@
+package export
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/concept-exporter/concept"
+	"github.com/Financial-Times/concept-exporter/db"
+	"github.com/Financial-Times/go-logger/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	supported := []string{"Brand", "Topic", "Organisation"}
+
+	entries, err := ParseSchedule("0 2 * * *; 0 3 * * 0 | Brand, Organisation", supported)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(entries))
+	assert.Equal(t, "0 2 * * *", entries[0].Spec)
+	assert.Equal(t, supported, entries[0].ConceptTypes)
+	assert.Equal(t, "0 3 * * 0", entries[1].Spec)
+	assert.Equal(t, []string{"Brand", "Organisation"}, entries[1].ConceptTypes)
+
+	_, err = ParseSchedule("0 2 * *", supported)
+	assert.Error(t, err)
+	_, err = ParseSchedule("0 2 * * *|Person", supported)
+	assert.Error(t, err)
+	_, err = ParseSchedule(" ; ", supported)
+	assert.Error(t, err)
+}
+
+func TestScheduler_NextRuns(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+	entries, err := ParseSchedule("0 3 * * *|Topic;0 * * * *|Brand", []string{"Brand", "Topic"})
+	require.NoError(t, err)
+	s := NewScheduler(NewFullExporter(1, 1, new(mockUpdater), &mockInquirer{}, SupportedExporters(), log), entries, log)
+
+	assert.Empty(t, s.NextRuns())
+
+	s.Start()
+	defer s.Stop()
+	runs := s.NextRuns()
+	require.Equal(t, 2, len(runs))
+	now := time.Now().UTC()
+	assert.Equal(t, []string{"Brand"}, runs[0].Concepts)
+	assert.Equal(t, "0 * * * *", runs[0].Schedule)
+	assert.Equal(t, now.Truncate(time.Hour).Add(time.Hour), runs[0].Time.UTC())
+	assert.Equal(t, []string{"Topic"}, runs[1].Concepts)
+	assert.True(t, runs[1].Time.After(now))
+	assert.Equal(t, 3, runs[1].Time.UTC().Hour())
+}
+
+func TestScheduler_Run(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, mock.Anything, "Brand.csv", mock.Anything).Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, ManifestFileName, mock.Anything).Return(nil)
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {{ID: "http://api.ft.com/things/1", PrefLabel: "FT"}},
+	}}
+	fe := NewFullExporter(1, 2, updater, inquirer, SupportedExporters(), log)
+	entries, err := ParseSchedule("0 2 * * *|Brand", []string{"Brand"})
+	require.NoError(t, err)
+	s := NewScheduler(fe, entries, log)
+
+	s.run(entries[0])
+
+	job := fe.GetCurrentJob()
+	assert.Equal(t, concept.FINISHED, job.Status)
+	assert.Equal(t, []string{"Brand"}, job.Concepts)
+	assert.Equal(t, []string{"Brand.csv", ManifestFileName}, job.Files)
+	updater.AssertExpectations(t)
+}
+
+func TestScheduler_RunSkippedWhileJobIsRunning(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+	fe := NewFullExporter(1, 2, new(mockUpdater), &mockInquirer{}, SupportedExporters(), log)
+	entries, err := ParseSchedule("0 2 * * *|Brand", []string{"Brand"})
+	require.NoError(t, err)
+	s := NewScheduler(fe, entries, log)
+
+	running := createJob(t, fe, []string{"Brand"}, Options{})
+	s.run(entries[0])
+
+	jobs := fe.GetJobs()
+	assert.Equal(t, 1, len(jobs))
+	assert.Equal(t, running.ID, jobs[0].ID)
+	assert.Equal(t, concept.STARTING, jobs[0].Status)
+}
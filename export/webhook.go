@@ -0,0 +1,112 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Financial-Times/concept-exporter/concept"
+	logger "github.com/Financial-Times/go-logger/v2"
+)
+
+const (
+	// SignatureHeader carries the hex encoded HMAC-SHA256 of the timestamp and payload of the webhook, keyed with the webhook secret
+	SignatureHeader = "X-Concept-Exporter-Signature"
+	// TimestampHeader carries the Unix time in seconds the webhook was signed at,
+	// subscribers reject the old ones so that a captured request can't be replayed
+	TimestampHeader = "X-Concept-Exporter-Timestamp"
+)
+
+// Notifier is told about every job once it is over
+type Notifier interface {
+	Notify(job *Job, tid string)
+}
+
+// WebhookNotifier posts the final state of a job as JSON to the service-wide webhook URLs and to the callback URL of the job.
+// Retries and backoff of the failed requests are left to the Client.
+type WebhookNotifier struct {
+	Client concept.Client
+	URLs   []string
+	Secret string
+	Log    *logger.UPPLogger
+}
+
+func NewWebhookNotifier(client concept.Client, urls []string, secret string, log *logger.UPPLogger) *WebhookNotifier {
+	return &WebhookNotifier{
+		Client: client,
+		URLs:   urls,
+		Secret: secret,
+		Log:    log,
+	}
+}
+
+func (n *WebhookNotifier) Notify(job *Job, tid string) {
+	urls := n.URLs
+	if job.CallbackURL != "" {
+		urls = append(urls[:len(urls):len(urls)], job.CallbackURL)
+	}
+	if len(urls) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(job)
+	if err != nil {
+		n.Log.WithTransactionID(tid).WithError(err).Errorf("Couldn't marshal job %v for the webhooks", job.ID)
+		return
+	}
+	for _, url := range urls {
+		if err := n.post(url, payload, tid); err != nil {
+			n.Log.WithTransactionID(tid).WithError(err).Errorf("Notifying %v about job %v failed", url, job.ID)
+			continue
+		}
+		n.Log.WithTransactionID(tid).Infof("Notified %v about job %v", url, job.ID)
+	}
+}
+
+func (n *WebhookNotifier) post(url string, payload []byte, tid string) error {
+	// the job context is already over, the client bounds the request with its own timeout
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("User-Agent", "UPP Concept Exporter")
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Request-Id", tid)
+	if n.Secret != "" {
+		// every retry sends the same request, signed once
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Add(TimestampHeader, timestamp)
+		req.Header.Add(SignatureHeader, "sha256="+Sign(timestamp, payload, n.Secret))
+	}
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned HTTP %v", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the timestamp, a dot and the payload,
+// subscribers compute it the same way to verify a webhook
+func Sign(timestamp string, payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
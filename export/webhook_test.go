@@ -0,0 +1,151 @@
+package export
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/concept-exporter/concept"
+	"github.com/Financial-Times/concept-exporter/db"
+	"github.com/Financial-Times/go-logger/v2"
+	"github.com/sethgrid/pester"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockNotifier struct {
+	mock.Mock
+}
+
+func (m *mockNotifier) Notify(job *Job, tid string) {
+	m.Called(job, tid)
+}
+
+type webhookRequest struct {
+	signature string
+	timestamp string
+	tid       string
+	body      []byte
+}
+
+func startWebhookServer(t *testing.T, failures int) (*httptest.Server, func() []webhookRequest) {
+	var mu sync.Mutex
+	var requests []webhookRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, webhookRequest{signature: r.Header.Get(SignatureHeader), timestamp: r.Header.Get(TimestampHeader), tid: r.Header.Get("X-Request-Id"), body: body})
+		if len(requests) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return server, func() []webhookRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+func newRetryingClient() *pester.Client {
+	client := pester.New()
+	client.Backoff = func(int) time.Duration { return time.Millisecond }
+	client.MaxRetries = 3
+	return client
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+	subscriber, subscriberRequests := startWebhookServer(t, 2)
+	defer subscriber.Close()
+	callback, callbackRequests := startWebhookServer(t, 0)
+	defer callback.Close()
+
+	n := NewWebhookNotifier(newRetryingClient(), []string{subscriber.URL}, "s3cr3t", log)
+	job := &Job{
+		ID:      "job_1",
+		Status:  concept.FINISHED,
+		Failed:  []string{"Topic"},
+		Files:   []string{"Brand.csv"},
+		Workers: []*concept.Worker{{ConceptType: "Brand", Count: 1, Progress: 1, Status: concept.FINISHED}},
+		Options: Options{Format: CSVFormat, CallbackURL: callback.URL},
+	}
+	n.Notify(job, "tid_1234")
+
+	requests := subscriberRequests()
+	require.Equal(t, 3, len(requests), "the subscriber should get the payload once it stops failing")
+	last := requests[2]
+	assert.Equal(t, "tid_1234", last.tid)
+	assert.Equal(t, "sha256="+Sign(last.timestamp, last.body, "s3cr3t"), last.signature)
+	signedAt, err := strconv.ParseInt(last.timestamp, 10, 64)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), time.Unix(signedAt, 0), time.Minute)
+	assert.NotEqual(t, Sign("1570000000", last.body, "s3cr3t"), Sign("1570000001", last.body, "s3cr3t"), "the timestamp should be signed")
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(last.body, &payload))
+	assert.Equal(t, "job_1", payload["ID"])
+	assert.Equal(t, "Finished", payload["Status"])
+	assert.Equal(t, []interface{}{"Topic"}, payload["Failed"])
+	assert.Equal(t, []interface{}{"Brand.csv"}, payload["Files"])
+	workers := payload["ConceptWorkers"].([]interface{})
+	assert.Equal(t, float64(1), workers[0].(map[string]interface{})["Count"])
+
+	require.Equal(t, 1, len(callbackRequests()))
+	assert.Equal(t, last.body, callbackRequests()[0].body)
+	assert.Equal(t, 1, len(n.URLs), "the callback URL of a job should not be kept")
+}
+
+func TestWebhookNotifier_NotifyUnsigned(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+	subscriber, subscriberRequests := startWebhookServer(t, 0)
+	defer subscriber.Close()
+
+	n := NewWebhookNotifier(newRetryingClient(), []string{subscriber.URL}, "", log)
+	n.Notify(&Job{ID: "job_1", Status: concept.CANCELLED}, "tid_1234")
+
+	require.Equal(t, 1, len(subscriberRequests()))
+	assert.Empty(t, subscriberRequests()[0].signature)
+	assert.Empty(t, subscriberRequests()[0].timestamp)
+}
+
+func TestFullExporter_RunFullExportNotifies(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, mock.Anything, "Brand.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, ManifestFileName, "tid_1234").Return(nil)
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {{ID: "http://api.ft.com/things/1", PrefLabel: "FT"}},
+	}}
+	notifier := new(mockNotifier)
+	notified := make(chan struct{})
+	notifier.On("Notify", mock.MatchedBy(func(job *Job) bool {
+		return job.Status == concept.FINISHED && job.EndTime != nil && len(job.Files) == 2 && job.CallbackURL == "http://localhost/callback"
+	}), "tid_1234").Run(func(mock.Arguments) {
+		// the export is over before the notification is sent
+		<-notified
+	}).Return()
+	fe := NewFullExporter(1, 1, updater, inquirer, SupportedExporters(), log)
+	fe.Notifier = notifier
+
+	job := createJob(t, fe, []string{"Brand"}, Options{CallbackURL: "http://localhost/callback"})
+	fe.RunFullExport(job, "tid_1234")
+	close(notified)
+	fe.WaitForNotifications()
+
+	notifier.AssertNumberOfCalls(t, "Notify", 1)
+	notifier.AssertExpectations(t)
+}
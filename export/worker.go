@@ -1,10 +1,20 @@
 package export
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/Financial-Times/concept-exporter/concept"
+	"github.com/Financial-Times/concept-exporter/db"
 	logger "github.com/Financial-Times/go-logger/v2"
 	"github.com/pborman/uuid"
 )
@@ -17,51 +27,258 @@ type Job struct {
 	Concepts     []string          `json:"Concepts,omitempty"`
 	Progress     []string          `json:"Progress,omitempty"`
 	Failed       []string          `json:"Failed,omitempty"`
+	Files        []string          `json:"Files,omitempty"`
 	Status       concept.State     `json:"Status"`
 	ErrorMessage string            `json:"ErrorMessage,omitempty"`
+	StartTime    *time.Time        `json:"StartTime,omitempty"`
+	EndTime      *time.Time        `json:"EndTime,omitempty"`
+	// Succeeded is set once every concept type was exported and the files and manifest were published,
+	// or described for a dry run. A job without it may have published some files.
+	Succeeded bool `json:"Succeeded,omitempty"`
+	// DryRunFiles are the files exported by a dry run
+	DryRunFiles []DryRunFile `json:"DryRunFiles,omitempty"`
+	Options
+	ctx      context.Context
+	cancel   context.CancelFunc
+	manifest []ManifestFile
+	// uuids are the sorted prefUUIDs of the concepts the running job exported by concept type,
+	// which become the baselines of the concept types if the job succeeds
+	uuids map[string][]string
+	// baseline has the uuids of the baselines a delta export takes the changes since, found when the job is created
+	baseline map[string][]string
+}
+
+// Options are the per request settings of a job
+type Options struct {
+	Format string `json:"Format,omitempty"`
+	// Since makes the job a delta export of the changes after the given time
+	Since *time.Time `json:"Since,omitempty"`
+	// CallbackURL is notified of the final state of the job, besides the service-wide webhooks
+	CallbackURL string `json:"CallbackURL,omitempty"`
+	// Compression of the exported files, the Compression of the FullExporter if empty
+	Compression string `json:"Compression,omitempty"`
+	// CSVColumns overrides the CSV columns of the concept types for this job
+	CSVColumns map[string][]string `json:"CSVColumns,omitempty"`
+	// Destination is the path prefix the files of the job are uploaded under, see ValidateDestination
+	Destination string `json:"Destination,omitempty"`
+	// DryRun exports the concepts without uploading the files, see Job.DryRunFiles
+	DryRun bool `json:"DryRun,omitempty"`
+	// Relationships adds the file of the relationships between the exported concepts, see RelationshipsFile
+	Relationships bool `json:"Relationships,omitempty"`
+	// Concordance adds the file of the identifiers of the exported concepts in other systems, see ConcordanceFile
+	Concordance bool `json:"Concordance,omitempty"`
+	// AnnotationStats adds the annotation usage statistics of the concepts, as the AnnotationStatsColumns for CSV
+	AnnotationStats bool `json:"AnnotationStats,omitempty"`
+	// MinAnnotations exports only the concepts with at least this number of annotations
+	MinAnnotations int `json:"MinAnnotations,omitempty"`
+	// AnnotatedSince exports only the concepts annotated at or after the given time
+	AnnotatedSince *time.Time `json:"AnnotatedSince,omitempty"`
+	// ExcludeDeprecated leaves the deprecated concepts out of the export
+	ExcludeDeprecated bool `json:"ExcludeDeprecated,omitempty"`
+	// IncludeUnannotated exports the unannotated concepts too, telling them apart with the AnnotatedColumn.
+	// It can't be combined with Since.
+	IncludeUnannotated bool `json:"IncludeUnannotated,omitempty"`
+}
+
+// destinationSegment is the grammar of every segment of a destination
+var destinationSegment = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ValidateDestination checks that the destination is a relative path of letters, digits, dots, dashes and underscores,
+// without empty, hidden or parent segments
+func ValidateDestination(destination string) error {
+	for _, segment := range strings.Split(destination, "/") {
+		if !destinationSegment.MatchString(segment) {
+			return fmt.Errorf("invalid destination %q", destination)
+		}
+	}
+	return nil
+}
+
+// destinationName returns the name a file of the job is published under
+func (job *Job) destinationName(fileName string) string {
+	return path.Join(job.Destination, fileName)
+}
+
+// generation is the set of files a job published atomically, under a path of its own
+type generation struct {
+	jobID string
+	files []string
+}
+
+var (
+	ErrJobNotFound   = errors.New("job not found")
+	ErrJobNotRunning = errors.New("job is not running")
+	ErrJobRunning    = errors.New("there is already a running job")
+)
+
+// baseline is the latest successful export of a concept type, which the delta exports of the type
+// taking the changes since it find their removed concepts with
+type baseline struct {
+	start   time.Time
+	options Options
+	// uuids are the sorted prefUUIDs of the exported concepts
+	uuids []string
 }
 
 type FullExporter struct {
 	sync.RWMutex
-	job                   *Job
+	job  *Job
+	jobs []*Job
+	// baselines keep the latest successful export of every concept type, even once its job left the history.
+	// They are lost on restart.
+	baselines map[string]*baseline
+	// generations keep the files of the latest job published atomically into every destination, to remove them
+	// once they are superseded. They are lost on restart.
+	generations           map[string]generation
+	JobHistorySize        int
 	NrOfConcurrentWorkers int
 	Updater               concept.Updater
 	Inquirer              concept.Inquirer
-	Exporter              *CsvExporter
-	Log                   *logger.UPPLogger
+	Exporters             map[string]NewExporterFunc
+	// Notifier is optional, it is told about every job once it is over, from a goroutine of its own
+	Notifier      Notifier
+	notifications sync.WaitGroup
+	// Compression is the default compression of the exported files
+	Compression string
+	// CSVColumns overrides the default CSV columns of the concept types
+	CSVColumns map[string][]string
+	// Relationships reads the relationships of the jobs exporting them
+	Relationships db.RelationshipService
+	// Concordances reads the identifiers of the concepts of the jobs exporting their concordance
+	Concordances db.ConcordanceService
+	// AtomicPublish uploads the files of every job under <destination>/<job ID>/ and publishes them all at once
+	// by uploading the manifest listing them last, only if every concept type succeeded.
+	// The files of failed and superseded jobs are removed if the Updater implements concept.Remover.
+	AtomicPublish bool
+	Log           *logger.UPPLogger
 }
 
-func NewFullExporter(nrOfWorkers int, exporter concept.Updater, inquirer concept.Inquirer, csvExporter *CsvExporter, log *logger.UPPLogger) *FullExporter {
+func NewFullExporter(nrOfWorkers, jobHistorySize int, exporter concept.Updater, inquirer concept.Inquirer, exporters map[string]NewExporterFunc, log *logger.UPPLogger) *FullExporter {
 	return &FullExporter{
 		NrOfConcurrentWorkers: nrOfWorkers,
+		JobHistorySize:        jobHistorySize,
 		Updater:               exporter,
 		Inquirer:              inquirer,
-		Exporter:              csvExporter,
+		Exporters:             exporters,
 		Log:                   log,
 	}
 }
 
-func (fe *FullExporter) IsRunningJob() bool {
+// IsSupportedFormat tells whether there is an exporter for the given output format
+func (fe *FullExporter) IsSupportedFormat(format string) bool {
+	_, found := fe.Exporters[format]
+	return found
+}
+
+func (fe *FullExporter) GetCurrentJob() Job {
 	fe.Lock()
 	defer fe.Unlock()
 	if fe.job == nil {
-		return false
+		return Job{}
 	}
-	return fe.job.Status == concept.RUNNING
+	return fe.getJob(fe.job)
 }
 
-func (fe *FullExporter) GetCurrentJob() Job {
+// GetJobs returns the jobs kept in the history, the most recent one first
+func (fe *FullExporter) GetJobs() []Job {
 	fe.Lock()
 	defer fe.Unlock()
-	if fe.job == nil {
-		return Job{}
+	jobs := make([]Job, 0, len(fe.jobs))
+	for i := len(fe.jobs) - 1; i >= 0; i-- {
+		jobs = append(jobs, fe.getJob(fe.jobs[i]))
 	}
-	return fe.getJob()
+	return jobs
+}
+
+// GetJob returns the job with the given ID if it is still kept in the history
+func (fe *FullExporter) GetJob(id string) (Job, bool) {
+	fe.Lock()
+	defer fe.Unlock()
+	for _, job := range fe.jobs {
+		if job.ID == id {
+			return fe.getJob(job), true
+		}
+	}
+	return Job{}, false
+}
+
+// GetLastSuccessfulJobStart returns the start time of the latest successful export of the given concept types,
+// selected like the given options do into the same destination, the earliest one for several concept types.
+// When the options have a Since, the exports have to have started at or before it.
+// It is the start of a delta export of the concept types with the given options.
+func (fe *FullExporter) GetLastSuccessfulJobStart(conceptTypes []string, options Options) (*time.Time, bool) {
+	fe.Lock()
+	defer fe.Unlock()
+	var start *time.Time
+	for _, cType := range conceptTypes {
+		b := fe.findBaseline(cType, options)
+		if b == nil {
+			return nil, false
+		}
+		if start == nil || b.start.Before(*start) {
+			start = &b.start
+		}
+	}
+	return start, start != nil
+}
+
+// findBaseline returns the baseline of the concept type a delta export with the given options can take the changes since,
+// or nil. The lock has to be held.
+func (fe *FullExporter) findBaseline(conceptType string, options Options) *baseline {
+	b, found := fe.baselines[conceptType]
+	if !found || !sameSelection(b.options, options) {
+		return nil
+	}
+	if options.Since != nil && b.start.After(*options.Since) {
+		return nil
+	}
+	return b
+}
+
+// takeBaseline returns the prefUUIDs of the baselines of the delta export of the job, which doesn't keep them
+func (fe *FullExporter) takeBaseline(job *Job) map[string][]string {
+	fe.Lock()
+	defer fe.Unlock()
+	baseline := job.baseline
+	job.baseline = nil
+	return baseline
+}
+
+// sameSelection tells whether jobs with the given options export the same concepts into the same destination
+func sameSelection(a, b Options) bool {
+	return a.Destination == b.Destination &&
+		max(a.MinAnnotations, 1) == max(b.MinAnnotations, 1) &&
+		sameTime(a.AnnotatedSince, b.AnnotatedSince) &&
+		a.ExcludeDeprecated == b.ExcludeDeprecated &&
+		a.IncludeUnannotated == b.IncludeUnannotated
 }
 
-func (fe *FullExporter) getJob() Job {
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func containsAll(list []string, items []string) bool {
+	for _, item := range items {
+		found := false
+		for _, el := range list {
+			if el == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (fe *FullExporter) getJob(job *Job) Job {
 	var workers []*concept.Worker
-	for _, w := range fe.job.Workers {
+	for _, w := range job.Workers {
 		workers = append(workers, &concept.Worker{
 			ConceptType:  w.ConceptType,
 			Progress:     w.Progress,
@@ -71,79 +288,377 @@ func (fe *FullExporter) getJob() Job {
 		})
 	}
 	return Job{
-		ID:           fe.job.ID,
-		Status:       fe.job.Status,
-		ErrorMessage: fe.job.ErrorMessage,
-		Concepts:     fe.job.Concepts,
-		Progress:     fe.job.Progress,
-		Failed:       fe.job.Failed,
+		ID:           job.ID,
+		Status:       job.Status,
+		ErrorMessage: job.ErrorMessage,
+		Concepts:     job.Concepts,
+		Progress:     job.Progress,
+		Failed:       job.Failed,
+		Files:        job.Files,
+		StartTime:    job.StartTime,
+		EndTime:      job.EndTime,
+		Succeeded:    job.Succeeded,
+		DryRunFiles:  job.DryRunFiles,
+		Options:      job.Options,
 		Workers:      workers,
 	}
 }
 
-func (fe *FullExporter) CreateJob(candidates []string, errMsg string) Job {
+// TryCreateJob creates the job to be run by RunFullExport, unless the current job is starting or running
+func (fe *FullExporter) TryCreateJob(candidates []string, options Options, errMsg string) (*Job, error) {
 	fe.Lock()
 	defer fe.Unlock()
-	fe.job = &Job{ID: "job_" + uuid.New(), NrWorker: fe.NrOfConcurrentWorkers, Status: concept.STARTING, Concepts: candidates, ErrorMessage: errMsg}
-	return fe.getJob()
+	if fe.job != nil && (fe.job.Status == concept.STARTING || fe.job.Status == concept.RUNNING) {
+		return nil, ErrJobRunning
+	}
+	return fe.createJob(candidates, options, errMsg), nil
 }
 
-func (fe *FullExporter) setJobStatus(state concept.State) {
+func (fe *FullExporter) createJob(candidates []string, options Options, errMsg string) *Job {
+	if options.Format == "" {
+		options.Format = CSVFormat
+	}
+	if options.Compression == "" {
+		options.Compression = fe.Compression
+	}
+	if options.Compression == "" {
+		options.Compression = NoCompression
+	}
+	id := "job_" + uuid.New()
+	ctx, cancel := context.WithCancel(concept.WithJobID(context.Background(), id))
+	fe.job = &Job{ID: id, NrWorker: fe.NrOfConcurrentWorkers, Status: concept.STARTING, Concepts: candidates, ErrorMessage: errMsg, Options: options, ctx: ctx, cancel: cancel}
+	// the baselines are found before another job can replace them
+	if options.Since != nil {
+		fe.job.baseline = map[string][]string{}
+		for _, cType := range candidates {
+			if b := fe.findBaseline(cType, options); b != nil {
+				fe.job.baseline[cType] = b.uuids
+			}
+		}
+	}
+	fe.jobs = append(fe.jobs, fe.job)
+	historySize := fe.JobHistorySize
+	if historySize < 1 {
+		historySize = 1
+	}
+	if len(fe.jobs) > historySize {
+		fe.jobs = fe.jobs[len(fe.jobs)-historySize:]
+	}
+	return fe.job
+}
+
+// CancelJob stops the job with the given ID if it is starting or running.
+// The job and its unfinished workers end up in the CANCELLED state once the export has stopped.
+func (fe *FullExporter) CancelJob(id string) (Job, error) {
 	fe.Lock()
 	defer fe.Unlock()
-	fe.job.Status = state
+	for _, job := range fe.jobs {
+		if job.ID != id {
+			continue
+		}
+		if job.Status != concept.STARTING && job.Status != concept.RUNNING {
+			return fe.getJob(job), ErrJobNotRunning
+		}
+		job.cancel()
+		return fe.getJob(job), nil
+	}
+	return Job{}, ErrJobNotFound
 }
 
-func (fe *FullExporter) setJobWorkers(workers []*concept.Worker) {
+func (fe *FullExporter) setJobStatus(job *Job, state concept.State) {
 	fe.Lock()
 	defer fe.Unlock()
-	fe.job.Workers = workers
+	job.Status = state
+	now := time.Now().UTC()
+	switch state {
+	case concept.FINISHED, concept.CANCELLED:
+		job.EndTime = &now
+	}
+}
+
+// startJob sets the given job running if it is the current job and hasn't started yet
+func (fe *FullExporter) startJob(job *Job) bool {
+	fe.Lock()
+	defer fe.Unlock()
+	if job == nil || job != fe.job || job.Status != concept.STARTING {
+		return false
+	}
+	now := time.Now().UTC()
+	job.Status = concept.RUNNING
+	job.StartTime = &now
+	return true
+}
+
+func (fe *FullExporter) setJobWorkers(job *Job, workers []*concept.Worker) {
+	fe.Lock()
+	defer fe.Unlock()
+	job.Workers = workers
 }
 
-func (fe *FullExporter) setJobErrorMessage(msg string) {
+func (fe *FullExporter) setJobErrorMessage(job *Job, msg string) {
 	fe.Lock()
 	defer fe.Unlock()
-	fe.job.ErrorMessage = msg
+	job.ErrorMessage = msg
 }
 
-func (fe *FullExporter) setJobProgress(cType string) {
+// setJobSucceeded marks the job as succeeded unless it was cancelled or some of its concept types failed.
+// The concepts a succeeded job exported replace the baselines of their concept types.
+func (fe *FullExporter) setJobSucceeded(ctx context.Context, job *Job) {
 	fe.Lock()
 	defer fe.Unlock()
-	fe.job.Progress = append(fe.job.Progress, cType)
+	job.Succeeded = ctx.Err() == nil && len(job.Failed) == 0
+	if job.Succeeded && len(job.uuids) != 0 {
+		if fe.baselines == nil {
+			fe.baselines = map[string]*baseline{}
+		}
+		for cType, uuids := range job.uuids {
+			fe.baselines[cType] = &baseline{start: *job.StartTime, options: job.Options, uuids: uuids}
+		}
+	}
+	job.uuids = nil
 }
 
-func (fe *FullExporter) setJobFailed(cType string) {
+func (fe *FullExporter) setJobUUIDs(job *Job, cType string, uuids []string) {
 	fe.Lock()
 	defer fe.Unlock()
-	fe.job.Failed = append(fe.job.Failed, cType)
+	if job.uuids == nil {
+		job.uuids = map[string][]string{}
+	}
+	job.uuids[cType] = uuids
 }
 
-func (fe *FullExporter) RunFullExport(tid string) {
+func (fe *FullExporter) setJobProgress(job *Job, cType string) {
+	fe.Lock()
+	defer fe.Unlock()
+	job.Progress = append(job.Progress, cType)
+}
+
+func (fe *FullExporter) setJobFailed(job *Job, cType string) {
+	fe.Lock()
+	defer fe.Unlock()
+	job.Failed = append(job.Failed, cType)
+}
+
+func (fe *FullExporter) addJobFile(job *Job, f file, rows int) {
+	fe.Lock()
+	defer fe.Unlock()
+	job.Files = append(job.Files, f.name)
+	job.manifest = append(job.manifest, newManifestFile(job, f, rows))
+}
+
+// uploadManifest uploads the manifest of the files uploaded so far, unless the job was cancelled or uploaded no file.
+// It tells whether the manifest was uploaded.
+func (fe *FullExporter) uploadManifest(ctx context.Context, job *Job, tid string) bool {
+	fe.Lock()
+	if ctx.Err() != nil || len(job.manifest) == 0 {
+		fe.Unlock()
+		return false
+	}
+	manifest, err := newManifest(job)
+	fe.Unlock()
+	if err == nil {
+		err = fe.Updater.Upload(ctx, bytes.NewReader(manifest), job.destinationName(ManifestFileName), tid)
+	}
+	if err != nil {
+		fe.Log.WithTransactionID(tid).WithError(err).Errorf("Upload of the manifest of job %v failed", job.ID)
+		fe.setJobErrorMessage(job, fmt.Sprintf("%s manifest upload failed: %s", job.ErrorMessage, err.Error()))
+		return false
+	}
+	fe.Lock()
+	defer fe.Unlock()
+	job.Files = append(job.Files, job.destinationName(ManifestFileName))
+	return true
+}
+
+// RunFullExport runs the given job created by TryCreateJob, if it is still the current job and hasn't started yet
+func (fe *FullExporter) RunFullExport(job *Job, tid string) {
 	logEntry := fe.Log.WithTransactionID(tid)
-	if fe.job == nil || fe.job.Status != concept.STARTING {
+	if !fe.startJob(job) {
 		logEntry.Error("No job to be run")
 		return
 	}
 
-	logEntry.Infof("Job started: %v", fe.job.ID)
-	fe.setJobStatus(concept.RUNNING)
+	ctx := job.ctx
+	logEntry.Infof("Job started: %v", job.ID)
+	defer fe.notify(job, tid)
 	defer func() {
-		fe.setJobStatus(concept.FINISHED)
-		logEntry.Infof("Finished job %v with failed concept(s): %v, progress: %v", fe.job.ID, fe.job.Failed, fe.job.Progress)
+		if ctx.Err() != nil {
+			fe.setJobStatus(job, concept.CANCELLED)
+			logEntry.Infof("Cancelled job %v with failed concept(s): %v, progress: %v", job.ID, job.Failed, job.Progress)
+			return
+		}
+		fe.setJobStatus(job, concept.FINISHED)
+		job.cancel()
+		logEntry.Infof("Finished job %v with failed concept(s): %v, progress: %v", job.ID, job.Failed, job.Progress)
 	}()
 
-	err := fe.Exporter.Prepare(fe.job.Concepts)
+	newExporter, found := fe.Exporters[job.Format]
+	if !found {
+		logEntry.Errorf("No exporter for format %v", job.Format)
+		fe.setJobErrorMessage(job, fmt.Sprintf("%s unsupported format %v", job.ErrorMessage, job.Format))
+		return
+	}
+	if !IsSupportedCompression(job.Compression) {
+		logEntry.Errorf("Unsupported compression %v", job.Compression)
+		fe.setJobErrorMessage(job, fmt.Sprintf("%s unsupported compression %v", job.ErrorMessage, job.Compression))
+		return
+	}
+	if job.IncludeUnannotated && job.Since != nil {
+		logEntry.Error("A delta export can't include unannotated concepts")
+		fe.setJobErrorMessage(job, fmt.Sprintf("%s a delta export can't include unannotated concepts", job.ErrorMessage))
+		return
+	}
+	if job.Destination != "" {
+		if err := ValidateDestination(job.Destination); err != nil {
+			logEntry.Error(err.Error())
+			fe.setJobErrorMessage(job, fmt.Sprintf("%s %s", job.ErrorMessage, err.Error()))
+			return
+		}
+	}
+	columns := mergeCSVColumns(fe.CSVColumns, job.CSVColumns)
+	// the statistics are read for the jobs asking for them or for some of their columns
+	readOpts := db.ReadOptions{
+		Since:             job.Since,
+		AnnotationStats:   job.AnnotationStats,
+		MinAnnotations:    job.MinAnnotations,
+		AnnotatedSince:    job.AnnotatedSince,
+		ExcludeDeprecated: job.ExcludeDeprecated,
+	}
+	if job.Since != nil {
+		readOpts.Baseline = fe.takeBaseline(job)
+	}
+	if job.IncludeUnannotated {
+		readOpts.IncludeUnannotated = true
+		columns = appendCSVColumns(columns, job.Concepts, AnnotatedColumn)
+	}
+	if job.AnnotationStats {
+		columns = appendCSVColumns(columns, job.Concepts, AnnotationStatsColumns...)
+	} else if job.Format == CSVFormat && hasAnnotationStatsColumns(columns, job.Concepts) {
+		readOpts.AnnotationStats = true
+	}
+	newExporter = withCompression(withCSVColumns(newExporter, columns), job.Compression)
+	output, err := newJobOutput(newExporter, job.Concepts, job.Since != nil)
 	if err != nil {
-		logEntry.Errorf("Preparing CSV writer failed: %v", err.Error())
-		fe.setJobErrorMessage(fmt.Sprintf("%s %s", fe.job.ErrorMessage, err.Error()))
+		logEntry.Errorf("Preparing %v writer failed: %v", job.Format, err.Error())
+		fe.setJobErrorMessage(job, fmt.Sprintf("%s %s", job.ErrorMessage, err.Error()))
+		return
+	}
+	defer output.close()
+
+	fe.setJobWorkers(job, fe.Inquirer.Inquire(ctx, job.Concepts, readOpts, tid))
+
+	poolSize := fe.NrOfConcurrentWorkers
+	if poolSize < 1 {
+		poolSize = 1
+	}
+	sem := make(chan struct{}, poolSize)
+	var wg sync.WaitGroup
+	for _, worker := range job.Workers {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(w *concept.Worker) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fe.runExport(ctx, job, output, w, readOpts.Baseline, tid)
+		}(worker)
+	}
+	wg.Wait()
+	for _, rf := range fe.recordFiles(job, readOpts) {
+		if ctx.Err() == nil {
+			fe.exportRecords(ctx, job, rf, tid)
+		}
+	}
+	if job.DryRun {
+		fe.setJobSucceeded(ctx, job)
 		return
 	}
+	if fe.AtomicPublish {
+		fe.publish(ctx, job, tid)
+		return
+	}
+	if fe.uploadManifest(ctx, job, tid) {
+		fe.setJobSucceeded(ctx, job)
+	}
+}
+
+// uploadName returns the name a file of the job is uploaded under,
+// in the generation of the job if the exporter publishes atomically
+func (fe *FullExporter) uploadName(job *Job, fileName string) string {
+	if fe.AtomicPublish && !job.DryRun {
+		return job.destinationName(path.Join(job.ID, fileName))
+	}
+	return job.destinationName(fileName)
+}
 
-	fe.setJobWorkers(fe.Inquirer.Inquire(fe.job.Concepts, tid))
+// publish uploads the manifest of the job if it was neither cancelled nor had failed concept types.
+// The manifest is the single write switching its destination to the generation of the job,
+// the one it supersedes is removed afterwards. Otherwise the files of the job are removed,
+// and the previous manifest keeps publishing the previous generation.
+func (fe *FullExporter) publish(ctx context.Context, job *Job, tid string) {
+	fe.Lock()
+	current := generation{jobID: job.ID, files: append([]string{}, job.Files...)}
+	failed := len(job.Failed) != 0
+	fe.Unlock()
 
-	for _, worker := range fe.job.Workers {
-		fe.runExport(worker, tid)
+	reason := "the job was cancelled or some concept types failed"
+	if ctx.Err() == nil && !failed {
+		if fe.uploadManifest(ctx, job, tid) {
+			fe.setJobSucceeded(ctx, job)
+			fe.Log.WithTransactionID(tid).Infof("Published files of job %v: %v", job.ID, current.files)
+			fe.Lock()
+			if fe.generations == nil {
+				fe.generations = make(map[string]generation)
+			}
+			superseded := fe.generations[job.Destination]
+			fe.generations[job.Destination] = current
+			fe.Unlock()
+			fe.remove(superseded, tid)
+			return
+		}
+		reason = "the manifest was not uploaded"
 	}
+
+	fe.remove(current, tid)
+	fe.Lock()
+	defer fe.Unlock()
+	job.ErrorMessage = fmt.Sprintf("%s nothing was published, %s", job.ErrorMessage, reason)
+	job.Files = nil
+	job.manifest = nil
+}
+
+// remove deletes the files of a generation which is not published, if the updater can remove files
+func (fe *FullExporter) remove(g generation, tid string) {
+	remover, ok := fe.Updater.(concept.Remover)
+	if !ok || len(g.files) == 0 {
+		return
+	}
+	// the job context may be over, the cleanup still has to run
+	if err := remover.Remove(concept.WithJobID(context.Background(), g.jobID), g.files, tid); err != nil {
+		fe.Log.WithTransactionID(tid).WithError(err).Warnf("Removing the unpublished files of job %v failed", g.jobID)
+	}
+}
+
+// notify tells the notifier about the final state of the job without waiting for it,
+// the retries of a slow subscriber don't hold back the next job
+func (fe *FullExporter) notify(job *Job, tid string) {
+	if fe.Notifier == nil {
+		return
+	}
+	fe.Lock()
+	final := fe.getJob(job)
+	fe.Unlock()
+	fe.notifications.Add(1)
+	go func() {
+		defer fe.notifications.Done()
+		fe.Notifier.Notify(&final, tid)
+	}()
+}
+
+// WaitForNotifications waits for the notifications of the jobs over so far to be sent, e.g. before exiting
+func (fe *FullExporter) WaitForNotifications() {
+	fe.notifications.Wait()
 }
 
 func (fe *FullExporter) setWorkerState(worker *concept.Worker, state concept.State) {
@@ -164,39 +679,94 @@ func (fe *FullExporter) incWorkerProgress(worker *concept.Worker) {
 	worker.Progress++
 }
 
-func (fe *FullExporter) runExport(worker *concept.Worker, tid string) {
+// runExport writes the concepts of the worker into the files of its concept type and uploads them.
+// Unless the job is a dry run or includes unannotated concepts, the prefUUIDs it exported are kept to become the baseline
+// of the concept type, applying the changes of a delta export to those of its baseline.
+// A delta export without a baseline for the concept type has no removed file, and keeps no prefUUIDs.
+func (fe *FullExporter) runExport(ctx context.Context, job *Job, output *jobOutput, worker *concept.Worker, baselines map[string][]string, tid string) {
 	fe.setWorkerState(worker, concept.RUNNING)
+	state := concept.FINISHED
 	defer func() {
-		fe.setWorkerState(worker, concept.FINISHED)
+		fe.setWorkerState(worker, state)
 	}()
-	fe.setJobProgress(worker.ConceptType)
-	for {
-		select {
-		case c, ok := <-worker.ConceptCh:
-			if !ok {
-				err := fe.Updater.Upload(fe.Exporter.GetBytes(worker.ConceptType), fe.Exporter.GetFileName(worker.ConceptType), tid)
-				if err != nil {
-					fe.Log.WithTransactionID(tid).Errorf("Upload to S3 Writer failed: %v", err)
-					fe.setJobFailed(worker.ConceptType)
-					fe.setWorkerErrorMessage(worker, fmt.Sprintf("%s %s", worker.ErrorMessage, err.Error()))
-				}
-
-				return
-			}
-			fe.incWorkerProgress(worker)
-			err := fe.Exporter.Write(c, worker.ConceptType, tid)
-			if err != nil {
-				fe.Log.WithTransactionID(tid).WithError(err).Warn("CSV exporter writing failed")
-			}
-		case err, ok := <-worker.Errch:
-			if !ok {
-				//channel closed
-				return
-			}
-			fe.setJobFailed(worker.ConceptType)
+	fe.setJobProgress(job, worker.ConceptType)
+	// rows counts the concepts written in the file of every change, and written has their prefUUIDs if they are kept
+	rows := make(map[string]int)
+	written := make(map[string][]string)
+	baseline, hasBaseline := baselines[worker.ConceptType]
+	keepUUIDs := !job.DryRun && !job.IncludeUnannotated && (job.Since == nil || hasBaseline)
+	for c := range worker.ConceptCh {
+		fe.incWorkerProgress(worker)
+		err := output.write(c, worker.ConceptType, tid)
+		if err != nil {
+			fe.Log.WithTransactionID(tid).WithError(err).Warn("Exporter writing failed")
+			continue
+		}
+		rows[c.Change]++
+		if keepUUIDs {
+			written[c.Change] = append(written[c.Change], c.UUID)
+		}
+	}
+	if err, ok := <-worker.Errch; ok {
+		if ctx.Err() != nil {
+			state = concept.CANCELLED
+			return
+		}
+		fe.setJobFailed(job, worker.ConceptType)
+		fe.setWorkerErrorMessage(worker, fmt.Sprintf("%s %s", worker.ErrorMessage, err.Error()))
+		return
+	}
+
+	files, err := output.files(worker.ConceptType)
+	if err != nil {
+		fe.Log.WithTransactionID(tid).WithError(err).Errorf("Completing the files of %v failed", worker.ConceptType)
+		fe.setJobFailed(job, worker.ConceptType)
+		fe.setWorkerErrorMessage(worker, fmt.Sprintf("%s %s", worker.ErrorMessage, err.Error()))
+		return
+	}
+	for _, f := range files {
+		if f.change == db.Removed && !hasBaseline {
+			continue
+		}
+		f.name = fe.uploadName(job, f.name)
+		if job.DryRun {
+			fe.addDryRunFile(job, f, rows[f.change], tid)
+			continue
+		}
+		err := fe.Updater.Upload(ctx, f.content, f.name, tid)
+		if err != nil && ctx.Err() != nil {
+			state = concept.CANCELLED
+			return
+		}
+		if err != nil {
+			fe.Log.WithTransactionID(tid).Errorf("Upload to S3 Writer failed: %v", err)
+			fe.setJobFailed(job, worker.ConceptType)
 			fe.setWorkerErrorMessage(worker, fmt.Sprintf("%s %s", worker.ErrorMessage, err.Error()))
 			return
 		}
+		fe.addJobFile(job, f, rows[f.change])
+	}
+	if keepUUIDs {
+		fe.setJobUUIDs(job, worker.ConceptType, exportedUUIDs(baseline, written))
 	}
+}
 
+// exportedUUIDs returns the sorted prefUUIDs of the concepts exported once the written changes are applied to the baseline,
+// which are the written concepts themselves for a full export
+func exportedUUIDs(baseline []string, written map[string][]string) []string {
+	removed := make(map[string]bool, len(written[db.Removed]))
+	for _, uuid := range written[db.Removed] {
+		removed[uuid] = true
+	}
+	uuids := make([]string, 0, len(baseline)+len(written[""])+len(written[db.Added]))
+	for _, uuid := range baseline {
+		if !removed[uuid] {
+			uuids = append(uuids, uuid)
+		}
+	}
+	for _, change := range []string{"", db.Added, db.Changed} {
+		uuids = append(uuids, written[change]...)
+	}
+	sort.Strings(uuids)
+	return slices.Compact(uuids)
 }
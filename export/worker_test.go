@@ -0,0 +1,637 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/concept-exporter/concept"
+	"github.com/Financial-Times/concept-exporter/db"
+	"github.com/Financial-Times/go-logger/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockUpdater struct {
+	mock.Mock
+}
+
+func (m *mockUpdater) Upload(ctx context.Context, content io.ReadSeeker, fileName, tid string) error {
+	args := m.Called(ctx, readAll(content), fileName, tid)
+	return args.Error(0)
+}
+
+// readAll returns the content of an uploaded file, so that the expected uploads can be matched with it
+func readAll(content io.ReadSeeker) []byte {
+	_, _ = content.Seek(0, io.SeekStart)
+	b, _ := io.ReadAll(content)
+	return b
+}
+
+type mockInquirer struct {
+	concepts map[string][]db.Concept
+	// opts are the options of the last inquiry
+	opts db.ReadOptions
+	// blocking makes every read wait for the job to be cancelled
+	blocking bool
+}
+
+func (m *mockInquirer) Inquire(ctx context.Context, candidates []string, opts db.ReadOptions, tid string) []*concept.Worker {
+	m.opts = opts
+	var workers []*concept.Worker
+	for _, cType := range candidates {
+		worker := &concept.Worker{ConceptType: cType, Errch: make(chan error, 2), ConceptCh: make(chan db.Concept), Status: concept.STARTING}
+		workers = append(workers, worker)
+		go func(w *concept.Worker) {
+			defer close(w.Errch)
+			if m.blocking {
+				<-ctx.Done()
+				close(w.ConceptCh)
+				w.Errch <- ctx.Err()
+				return
+			}
+			for _, c := range m.concepts[w.ConceptType] {
+				w.ConceptCh <- c
+			}
+			close(w.ConceptCh)
+		}(worker)
+	}
+	return workers
+}
+
+// createJob creates a job to be run by RunFullExport, failing the test if another job is starting or running
+func createJob(t *testing.T, fe *FullExporter, candidates []string, options Options) *Job {
+	t.Helper()
+	job, err := fe.TryCreateJob(candidates, options, "")
+	require.NoError(t, err)
+	return job
+}
+
+// runJob creates a job of a new exporter with TryCreateJob and runs it, returning the exporter and the job once it is over.
+// The exporter has a single worker and keeps a single job, configure changes it before the job is created.
+func runJob(t *testing.T, updater concept.Updater, inquirer concept.Inquirer, candidates []string, options Options, configure ...func(fe *FullExporter)) (*FullExporter, *Job) {
+	t.Helper()
+	fe := NewFullExporter(1, 1, updater, inquirer, SupportedExporters(), logger.NewUPPLogger("Test", "PANIC"))
+	for _, c := range configure {
+		c(fe)
+	}
+	job := createJob(t, fe, candidates, options)
+	fe.RunFullExport(job, "tid_1234")
+	result, found := fe.GetJob(job.ID)
+	require.True(t, found)
+	return fe, &result
+}
+
+// addBaselineJob adds a successful job to the history which exported the given prefUUIDs by concept type,
+// replacing their baselines
+func addBaselineJob(t *testing.T, fe *FullExporter, start time.Time, options Options, uuids map[string][]string) {
+	var conceptTypes []string
+	for cType := range uuids {
+		conceptTypes = append(conceptTypes, cType)
+	}
+	job := createJob(t, fe, conceptTypes, options)
+	job.Status = concept.FINISHED
+	job.StartTime = &start
+	job.uuids = uuids
+	fe.setJobSucceeded(context.Background(), job)
+}
+
+func TestFullExporter_RunFullExport(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, mock.Anything, "Brand.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, ManifestFileName, "tid_1234").Return(nil)
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {{ID: "http://api.ft.com/things/1", PrefLabel: "FT"}},
+	}}
+	fe := NewFullExporter(1, 1, updater, inquirer, SupportedExporters(), log)
+
+	job := createJob(t, fe, []string{"Brand"}, Options{})
+	fe.RunFullExport(job, "tid_1234")
+
+	result, found := fe.GetJob(job.ID)
+	assert.True(t, found)
+	assert.Equal(t, concept.FINISHED, result.Status)
+	assert.Equal(t, []string{"Brand.csv", ManifestFileName}, result.Files)
+	assert.Empty(t, result.Failed)
+	assert.True(t, result.Succeeded)
+	assert.NotNil(t, result.StartTime)
+	assert.NotNil(t, result.EndTime)
+	assert.Equal(t, 1, result.Workers[0].Progress)
+	updater.AssertExpectations(t)
+	assert.Equal(t, []string{""}, fe.baselines["Brand"].uuids, "the exported concepts should be kept for the delta exports")
+	assert.Equal(t, *result.StartTime, fe.baselines["Brand"].start)
+}
+
+func TestFullExporter_RunFullExportWithFormat(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, []byte("{\"id\":\"http://api.ft.com/things/1\",\"uuid\":\"1\",\"prefLabel\":\"FT\",\"apiUrl\":\"\"}\n"), "Brand.jsonl", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, ManifestFileName, "tid_1234").Return(nil)
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {{ID: "http://api.ft.com/things/1", UUID: "1", PrefLabel: "FT"}},
+	}}
+	fe := NewFullExporter(1, 1, updater, inquirer, SupportedExporters(), log)
+
+	job := createJob(t, fe, []string{"Brand"}, Options{Format: JSONLinesFormat})
+	fe.RunFullExport(job, "tid_1234")
+
+	result, _ := fe.GetJob(job.ID)
+	assert.Equal(t, JSONLinesFormat, result.Format)
+	assert.Equal(t, []string{"Brand.jsonl", ManifestFileName}, result.Files)
+	updater.AssertExpectations(t)
+}
+
+func TestFullExporter_RunFullExportWithCompression(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, mock.Anything, "Brand.csv.gz", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, ManifestFileName, "tid_1234").Return(nil)
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {{ID: "http://api.ft.com/things/1", UUID: "1", PrefLabel: "FT"}},
+	}}
+	fe := NewFullExporter(1, 1, updater, inquirer, SupportedExporters(), log)
+	fe.Compression = ZstdCompression
+
+	job := createJob(t, fe, []string{"Brand"}, Options{Compression: GzipCompression})
+	fe.RunFullExport(job, "tid_1234")
+
+	result, _ := fe.GetJob(job.ID)
+	assert.Equal(t, GzipCompression, result.Compression, "the compression of the job should override the default one")
+	assert.Equal(t, []string{"Brand.csv.gz", ManifestFileName}, result.Files)
+	updater.AssertExpectations(t)
+}
+
+func TestFullExporter_RunFullExportWithUnsupportedCompression(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	updater := new(mockUpdater)
+	fe := NewFullExporter(1, 1, updater, &mockInquirer{}, SupportedExporters(), log)
+	fe.Compression = "brotli"
+
+	job := createJob(t, fe, []string{"Brand"}, Options{})
+	fe.RunFullExport(job, "tid_1234")
+
+	result, _ := fe.GetJob(job.ID)
+	assert.Contains(t, result.ErrorMessage, "unsupported compression brotli")
+	assert.Equal(t, concept.FINISHED, result.Status)
+	assert.False(t, result.Succeeded)
+	updater.AssertNotCalled(t, "Upload", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	_, found := fe.GetLastSuccessfulJobStart([]string{"Brand"}, Options{})
+	assert.False(t, found, "a job which exported nothing should not be the start of a delta export")
+}
+
+func TestFullExporter_RunFullExportWithCSVColumns(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, []byte("uuid,prefLabel\n1,FT\n"), "Brand.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, []byte("prefLabel\nBrexit\n"), "Topic.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, ManifestFileName, "tid_1234").Return(nil)
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {{ID: "http://api.ft.com/things/1", UUID: "1", PrefLabel: "FT"}},
+		"Topic": {{ID: "http://api.ft.com/things/2", UUID: "2", PrefLabel: "Brexit"}},
+	}}
+	fe := NewFullExporter(1, 1, updater, inquirer, SupportedExporters(), log)
+	fe.CSVColumns = map[string][]string{"Brand": {"id"}, "Topic": {"prefLabel"}}
+
+	job := createJob(t, fe, []string{"Brand", "Topic"}, Options{CSVColumns: map[string][]string{"Brand": {"uuid", "prefLabel"}}})
+	fe.RunFullExport(job, "tid_1234")
+
+	updater.AssertExpectations(t)
+}
+
+func TestFullExporter_RunFullExportWithAnnotationStats(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, []byte("uuid,mentions,about,isClassifiedBy,hasAuthor,hasBrand,firstAnnotated,lastAnnotated\n1,1,0,0,0,2,,\n"), "Brand.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, ManifestFileName, "tid_1234").Return(nil)
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {{ID: "http://api.ft.com/things/1", UUID: "1", PrefLabel: "FT", Annotations: &db.AnnotationStats{Mentions: 1, HasBrand: 2}}},
+	}}
+	fe := NewFullExporter(1, 1, updater, inquirer, SupportedExporters(), log)
+
+	job := createJob(t, fe, []string{"Brand"}, Options{AnnotationStats: true, CSVColumns: map[string][]string{"Brand": {"uuid"}}})
+	fe.RunFullExport(job, "tid_1234")
+
+	assert.True(t, inquirer.opts.AnnotationStats)
+	updater.AssertExpectations(t)
+}
+
+func TestFullExporter_RunFullExportWithAnnotationStatsColumns(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, mock.Anything, mock.Anything, "tid_1234").Return(nil)
+	inquirer := &mockInquirer{}
+	fe := NewFullExporter(1, 1, updater, inquirer, SupportedExporters(), log)
+
+	job := createJob(t, fe, []string{"Brand"}, Options{})
+	fe.RunFullExport(job, "tid_1234")
+	assert.False(t, inquirer.opts.AnnotationStats)
+
+	job = createJob(t, fe, []string{"Brand"}, Options{CSVColumns: map[string][]string{"Brand": {"id", "mentions"}}})
+	fe.RunFullExport(job, "tid_1234")
+	assert.True(t, inquirer.opts.AnnotationStats, "the statistics should be read for the columns asking for them")
+}
+
+func TestFullExporter_RunFullExportWithFilters(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, mock.Anything, mock.Anything, "tid_1234").Return(nil)
+	inquirer := &mockInquirer{}
+	fe := NewFullExporter(1, 1, updater, inquirer, SupportedExporters(), log)
+
+	annotatedSince := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	job := createJob(t, fe, []string{"Brand"}, Options{MinAnnotations: 5, AnnotatedSince: &annotatedSince, ExcludeDeprecated: true})
+	fe.RunFullExport(job, "tid_1234")
+
+	assert.Equal(t, db.ReadOptions{MinAnnotations: 5, AnnotatedSince: &annotatedSince, ExcludeDeprecated: true}, inquirer.opts)
+}
+
+func TestFullExporter_RunFullExportWithUnannotated(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	annotated, unannotated := true, false
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, []byte("uuid,annotated\n1,true\n2,false\n"), "Brand.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, ManifestFileName, "tid_1234").Return(nil)
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {{UUID: "1", Annotated: &annotated}, {UUID: "2", Annotated: &unannotated}},
+	}}
+	fe := NewFullExporter(1, 1, updater, inquirer, SupportedExporters(), log)
+
+	job := createJob(t, fe, []string{"Brand"}, Options{IncludeUnannotated: true, CSVColumns: map[string][]string{"Brand": {"uuid"}}})
+	fe.RunFullExport(job, "tid_1234")
+
+	assert.True(t, inquirer.opts.IncludeUnannotated)
+	updater.AssertExpectations(t)
+}
+
+func TestFullExporter_RunDeltaExportWithUnannotated(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	updater := new(mockUpdater)
+	fe := NewFullExporter(1, 1, updater, &mockInquirer{}, SupportedExporters(), log)
+
+	since := time.Now()
+	job := createJob(t, fe, []string{"Brand"}, Options{IncludeUnannotated: true, Since: &since})
+	fe.RunFullExport(job, "tid_1234")
+
+	result, _ := fe.GetJob(job.ID)
+	assert.Contains(t, result.ErrorMessage, "a delta export can't include unannotated concepts")
+	updater.AssertNotCalled(t, "Upload", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestFullExporter_RunFullExportWithDestination(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, mock.Anything, "reexport/2019/Brand.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, "reexport/2019/"+ManifestFileName, "tid_1234").Return(nil)
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {{ID: "http://api.ft.com/things/1", UUID: "1", PrefLabel: "FT"}},
+	}}
+	fe := NewFullExporter(1, 1, updater, inquirer, SupportedExporters(), log)
+
+	job := createJob(t, fe, []string{"Brand"}, Options{Destination: "reexport/2019"})
+	fe.RunFullExport(job, "tid_1234")
+
+	result, _ := fe.GetJob(job.ID)
+	assert.Equal(t, []string{"reexport/2019/Brand.csv", "reexport/2019/" + ManifestFileName}, result.Files)
+	updater.AssertExpectations(t)
+}
+
+func TestValidateDestination(t *testing.T) {
+	for _, valid := range []string{"reexport", "reexport/2019-10-01", "a/b_c/d.e"} {
+		assert.NoError(t, ValidateDestination(valid), valid)
+	}
+	for _, invalid := range []string{"", "/reexport", "reexport/", "../reexport", "reexport/../..", ".hidden", "a//b", "a b"} {
+		assert.Error(t, ValidateDestination(invalid), invalid)
+	}
+}
+
+func TestFullExporter_RunDeltaExport(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, []byte("id,prefLabel,apiUrl,alternativeLabels\nhttp://api.ft.com/things/1,FT,,\n"), "Brand-added.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, []byte("id,prefLabel,apiUrl,alternativeLabels\nhttp://api.ft.com/things/3,FT Alphaville,,\n"), "Brand-changed.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, []byte("id,prefLabel,apiUrl,alternativeLabels\nhttp://api.ft.com/things/2,FT Weekend,,\n"), "Brand-removed.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, ManifestFileName, "tid_1234").Return(nil)
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {
+			{ID: "http://api.ft.com/things/1", UUID: "1", PrefLabel: "FT", Change: db.Added},
+			{ID: "http://api.ft.com/things/3", UUID: "3", PrefLabel: "FT Alphaville", Change: db.Changed},
+			{ID: "http://api.ft.com/things/2", UUID: "2", PrefLabel: "FT Weekend", Change: db.Removed},
+		},
+	}}
+	fe := NewFullExporter(1, 1, updater, inquirer, SupportedExporters(), log)
+
+	_, found := fe.GetLastSuccessfulJobStart([]string{"Brand"}, Options{})
+	assert.False(t, found)
+
+	start := time.Now().UTC()
+	full := createJob(t, fe, []string{"Brand", "Topic"}, Options{})
+	full.Status = concept.FINISHED
+	full.StartTime = &start
+	full.uuids = map[string][]string{"Brand": {"2", "3", "4"}, "Topic": {"5"}}
+	full.Failed = []string{"Topic"}
+	fe.setJobSucceeded(context.Background(), full)
+	_, found = fe.GetLastSuccessfulJobStart([]string{"Brand"}, Options{})
+	assert.False(t, found, "a finished job which didn't succeed should not be the start of a delta export")
+
+	addBaselineJob(t, fe, start, Options{}, map[string][]string{"Brand": {"2", "3", "4"}, "Topic": {"5"}})
+	since, found := fe.GetLastSuccessfulJobStart([]string{"Brand"}, Options{})
+	assert.True(t, found)
+	assert.Equal(t, &start, since)
+
+	job := createJob(t, fe, []string{"Brand"}, Options{Since: since})
+	fe.RunFullExport(job, "tid_1234")
+
+	result, _ := fe.GetJob(job.ID)
+	assert.Equal(t, concept.FINISHED, result.Status)
+	assert.True(t, result.Succeeded)
+	assert.Equal(t, []string{"Brand-added.csv", "Brand-changed.csv", "Brand-removed.csv", ManifestFileName}, result.Files)
+	assert.NotEqual(t, full.ID, job.ID)
+	assert.Equal(t, map[string][]string{"Brand": {"2", "3", "4"}}, inquirer.opts.Baseline)
+	assert.Equal(t, []string{"1", "3", "4"}, fe.baselines["Brand"].uuids, "the changes should be applied to the concepts of the baseline")
+	assert.Equal(t, *result.StartTime, fe.baselines["Brand"].start)
+	assert.Equal(t, []string{"5"}, fe.baselines["Topic"].uuids, "the baselines of the other concept types should be kept")
+	assert.Empty(t, fe.jobs[0].uuids, "the baselines should not be kept by the jobs")
+	updater.AssertExpectations(t)
+}
+
+func TestFullExporter_RunDeltaExportWithoutBaseline(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	updater := new(mockUpdater)
+	updater.On("Upload", mock.Anything, []byte("id,prefLabel,apiUrl,alternativeLabels\nhttp://api.ft.com/things/2,FT Weekend,,\n"), "Brand-added.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, []byte("id,prefLabel,apiUrl,alternativeLabels\n"), "Brand-changed.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, ManifestFileName, "tid_1234").Return(nil)
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {{ID: "http://api.ft.com/things/2", UUID: "2", PrefLabel: "FT Weekend", Change: db.Added}},
+	}}
+	fe := NewFullExporter(1, 2, updater, inquirer, SupportedExporters(), log)
+	start := time.Date(2019, 10, 1, 2, 0, 0, 0, time.UTC)
+	addBaselineJob(t, fe, start, Options{}, map[string][]string{"Brand": {"1"}})
+
+	// the baseline started after the since, the removed concepts can't be found
+	before := start.Add(-time.Hour)
+	job := createJob(t, fe, []string{"Brand"}, Options{Since: &before})
+	fe.RunFullExport(job, "tid_1234")
+
+	result, _ := fe.GetJob(job.ID)
+	assert.True(t, result.Succeeded)
+	assert.Equal(t, []string{"Brand-added.csv", "Brand-changed.csv", ManifestFileName}, result.Files, "only the removed file should be skipped")
+	assert.Empty(t, inquirer.opts.Baseline)
+	assert.Equal(t, []string{"1"}, fe.baselines["Brand"].uuids, "a delta export without a baseline should not replace it")
+	updater.AssertExpectations(t)
+
+	after := start.Add(time.Hour)
+	since, found := fe.GetLastSuccessfulJobStart([]string{"Brand"}, Options{Since: &after})
+	assert.True(t, found)
+	assert.Equal(t, &start, since)
+}
+
+func TestFullExporter_GetLastSuccessfulJobStartOfSameSelection(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+	fe := NewFullExporter(1, 1, new(mockUpdater), &mockInquirer{}, SupportedExporters(), log)
+
+	annotatedSince := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	options := Options{Destination: "reexport", MinAnnotations: 5, AnnotatedSince: &annotatedSince, ExcludeDeprecated: true}
+	start := time.Now().UTC()
+	addBaselineJob(t, fe, start, options, map[string][]string{"Brand": {"1"}})
+
+	sameAnnotatedSince := annotatedSince.In(time.Local)
+	since, found := fe.GetLastSuccessfulJobStart([]string{"Brand"}, Options{Destination: "reexport", MinAnnotations: 5, AnnotatedSince: &sameAnnotatedSince, ExcludeDeprecated: true, Format: JSONLinesFormat})
+	assert.True(t, found)
+	assert.Equal(t, &start, since)
+
+	for name, other := range map[string]Options{
+		"destination":     {MinAnnotations: 5, AnnotatedSince: &annotatedSince, ExcludeDeprecated: true},
+		"min annotations": {Destination: "reexport", AnnotatedSince: &annotatedSince, ExcludeDeprecated: true},
+		"annotated since": {Destination: "reexport", MinAnnotations: 5, ExcludeDeprecated: true},
+		"deprecated":      {Destination: "reexport", MinAnnotations: 5, AnnotatedSince: &annotatedSince},
+	} {
+		_, found := fe.GetLastSuccessfulJobStart([]string{"Brand"}, other)
+		assert.False(t, found, name)
+	}
+}
+
+func TestFullExporter_JobHistory(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+	fe := NewFullExporter(1, 2, new(mockUpdater), &mockInquirer{}, SupportedExporters(), log)
+
+	first := createJob(t, fe, []string{"Brand"}, Options{})
+	first.Status = concept.FINISHED
+	second := createJob(t, fe, []string{"Topic"}, Options{})
+	second.Status = concept.FINISHED
+	third := createJob(t, fe, []string{"Person"}, Options{})
+
+	jobs := fe.GetJobs()
+	assert.Equal(t, 2, len(jobs))
+	assert.Equal(t, third.ID, jobs[0].ID)
+	assert.Equal(t, second.ID, jobs[1].ID)
+	assert.Equal(t, third.ID, fe.GetCurrentJob().ID)
+
+	_, found := fe.GetJob(first.ID)
+	assert.False(t, found)
+	job, found := fe.GetJob(second.ID)
+	assert.True(t, found)
+	assert.Equal(t, []string{"Topic"}, job.Concepts)
+}
+
+func TestFullExporter_TryCreateJob(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+	fe := NewFullExporter(1, 2, new(mockUpdater), &mockInquirer{}, SupportedExporters(), log)
+
+	starting, err := fe.TryCreateJob([]string{"Brand"}, Options{}, "")
+	require.NoError(t, err)
+	_, err = fe.TryCreateJob([]string{"Topic"}, Options{}, "")
+	assert.Equal(t, ErrJobRunning, err, "a starting job should not be replaced")
+
+	fe.job.Status = concept.RUNNING
+	_, err = fe.TryCreateJob([]string{"Topic"}, Options{}, "")
+	assert.Equal(t, ErrJobRunning, err, "a running job should not be replaced")
+	assert.Equal(t, starting.ID, fe.GetCurrentJob().ID)
+
+	fe.job.Status = concept.FINISHED
+	next, err := fe.TryCreateJob([]string{"Topic"}, Options{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, next.ID, fe.GetCurrentJob().ID)
+	assert.Equal(t, concept.STARTING, next.Status)
+}
+
+func TestFullExporter_RunFullExportOfReplacedJob(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+	updater := new(mockUpdater)
+	fe := NewFullExporter(1, 2, updater, &mockInquirer{}, SupportedExporters(), log)
+
+	replaced := createJob(t, fe, []string{"Brand"}, Options{})
+	replaced.Status = concept.CANCELLED
+	current := createJob(t, fe, []string{"Topic"}, Options{})
+	fe.RunFullExport(replaced, "tid_1234")
+
+	assert.Equal(t, concept.STARTING, current.Status, "only the current job should be run")
+	result, _ := fe.GetJob(replaced.ID)
+	assert.Equal(t, concept.CANCELLED, result.Status)
+	updater.AssertNotCalled(t, "Upload", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestFullExporter_CancelJob(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	updater := new(mockUpdater)
+	fe := NewFullExporter(2, 1, updater, &mockInquirer{blocking: true}, SupportedExporters(), log)
+
+	job := createJob(t, fe, []string{"Brand", "Topic"}, Options{})
+	done := make(chan struct{})
+	go func() {
+		fe.RunFullExport(job, "tid_1234")
+		close(done)
+	}()
+
+	_, err := fe.CancelJob(job.ID)
+	assert.NoError(t, err)
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("export did not stop after cancellation")
+	}
+
+	result := fe.GetCurrentJob()
+	assert.Equal(t, concept.CANCELLED, result.Status)
+	assert.Empty(t, result.Failed)
+	assert.NotNil(t, result.EndTime)
+	for _, w := range result.Workers {
+		assert.Equal(t, concept.CANCELLED, w.Status)
+	}
+	updater.AssertNotCalled(t, "Upload", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	_, err = fe.CancelJob(job.ID)
+	assert.ErrorIs(t, err, ErrJobNotRunning)
+	_, err = fe.CancelJob("job_unknown")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
+
+type mockRemovingUpdater struct {
+	mockUpdater
+}
+
+func (m *mockRemovingUpdater) Remove(ctx context.Context, fileNames []string, tid string) error {
+	args := m.Called(ctx, fileNames, tid)
+	return args.Error(0)
+}
+
+func TestFullExporter_AtomicPublish(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	updater := new(mockRemovingUpdater)
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {{ID: "http://api.ft.com/things/1", PrefLabel: "FT"}},
+		"Topic": {{ID: "http://api.ft.com/things/2", PrefLabel: "Brexit"}},
+	}}
+	fe := NewFullExporter(2, 2, updater, inquirer, SupportedExporters(), log)
+	fe.AtomicPublish = true
+
+	var manifestUploaded bool
+	first := createJob(t, fe, []string{"Brand", "Topic"}, Options{Destination: "reexport"})
+	for _, name := range []string{"Brand.csv", "Topic.csv"} {
+		updater.On("Upload", mock.Anything, mock.Anything, "reexport/"+first.ID+"/"+name, "tid_1234").Run(func(mock.Arguments) {
+			assert.False(t, manifestUploaded, "the manifest should be uploaded last")
+		}).Return(nil)
+	}
+	updater.On("Upload", mock.Anything, mock.MatchedBy(func(content []byte) bool {
+		var m Manifest
+		return json.Unmarshal(content, &m) == nil && len(m.Files) == 2 &&
+			m.Files[0].Name == "reexport/"+m.JobID+"/Brand.csv" && m.Files[1].Name == "reexport/"+m.JobID+"/Topic.csv"
+	}), "reexport/"+ManifestFileName, "tid_1234").Run(func(mock.Arguments) {
+		manifestUploaded = true
+	}).Return(nil)
+	fe.RunFullExport(first, "tid_1234")
+
+	result, _ := fe.GetJob(first.ID)
+	assert.True(t, result.Succeeded)
+	assert.ElementsMatch(t, []string{"reexport/" + first.ID + "/Brand.csv", "reexport/" + first.ID + "/Topic.csv", "reexport/" + ManifestFileName}, result.Files)
+	updater.AssertNotCalled(t, "Remove", mock.Anything, mock.Anything, mock.Anything)
+
+	// the next job publishing into the same destination supersedes the files of the first one
+	second := createJob(t, fe, []string{"Brand", "Topic"}, Options{Destination: "reexport"})
+	updater.On("Upload", mock.Anything, mock.Anything, mock.MatchedBy(func(name string) bool {
+		return strings.HasPrefix(name, "reexport/"+second.ID+"/")
+	}), "tid_1234").Return(nil)
+	updater.On("Remove", mock.MatchedBy(func(ctx context.Context) bool {
+		return ctx.Err() == nil && concept.JobIDFromContext(ctx) == first.ID
+	}), mock.MatchedBy(func(files []string) bool {
+		return assert.ElementsMatch(t, []string{"reexport/" + first.ID + "/Brand.csv", "reexport/" + first.ID + "/Topic.csv"}, files)
+	}), "tid_1234").Return(nil)
+	fe.RunFullExport(second, "tid_1234")
+
+	result, _ = fe.GetJob(second.ID)
+	assert.True(t, result.Succeeded)
+	updater.AssertExpectations(t)
+}
+
+func TestFullExporter_AtomicPublishRemovesFilesOnFailure(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	updater := new(mockRemovingUpdater)
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {{ID: "http://api.ft.com/things/1", PrefLabel: "FT"}},
+		"Topic": {{ID: "http://api.ft.com/things/2", PrefLabel: "Brexit"}},
+	}}
+	fe := NewFullExporter(1, 1, updater, inquirer, SupportedExporters(), log)
+	fe.AtomicPublish = true
+
+	job := createJob(t, fe, []string{"Brand", "Topic"}, Options{})
+	updater.On("Upload", mock.Anything, mock.Anything, job.ID+"/Brand.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, job.ID+"/Topic.csv", "tid_1234").Return(errors.New("S3 writer unavailable"))
+	updater.On("Remove", mock.MatchedBy(func(ctx context.Context) bool {
+		return ctx.Err() == nil && concept.JobIDFromContext(ctx) == job.ID
+	}), []string{job.ID + "/Brand.csv"}, "tid_1234").Return(nil)
+	fe.RunFullExport(job, "tid_1234")
+
+	result, _ := fe.GetJob(job.ID)
+	assert.Equal(t, []string{"Topic"}, result.Failed)
+	assert.Empty(t, result.Files)
+	assert.Contains(t, result.ErrorMessage, "nothing was published")
+	updater.AssertExpectations(t)
+	updater.AssertNotCalled(t, "Upload", mock.Anything, mock.Anything, ManifestFileName, mock.Anything)
+}
+
+func TestFullExporter_AtomicPublishRemovesFilesOnManifestFailure(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+
+	updater := new(mockRemovingUpdater)
+	inquirer := &mockInquirer{concepts: map[string][]db.Concept{
+		"Brand": {{ID: "http://api.ft.com/things/1", PrefLabel: "FT"}},
+	}}
+	fe := NewFullExporter(1, 1, updater, inquirer, SupportedExporters(), log)
+	fe.AtomicPublish = true
+
+	job := createJob(t, fe, []string{"Brand"}, Options{})
+	updater.On("Upload", mock.Anything, mock.Anything, job.ID+"/Brand.csv", "tid_1234").Return(nil)
+	updater.On("Upload", mock.Anything, mock.Anything, ManifestFileName, "tid_1234").Return(errors.New("S3 writer unavailable"))
+	updater.On("Remove", mock.Anything, []string{job.ID + "/Brand.csv"}, "tid_1234").Return(nil)
+	fe.RunFullExport(job, "tid_1234")
+
+	result, _ := fe.GetJob(job.ID)
+	assert.False(t, result.Succeeded)
+	assert.Empty(t, result.Files)
+	assert.Contains(t, result.ErrorMessage, "manifest upload failed")
+	assert.Contains(t, result.ErrorMessage, "nothing was published, the manifest was not uploaded")
+	updater.AssertExpectations(t)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Financial-Times/concept-exporter/concept"
+	"github.com/Financial-Times/concept-exporter/export"
+	transactionidutils "github.com/Financial-Times/transactionid-utils-go"
+)
+
+// progressInterval is how often the export command prints the progress of the running job
+const progressInterval = 5 * time.Second
+
+// runExportCommand runs an export synchronously, printing its progress, and returns the exit code of the command.
+// The exit code is not zero if the export could not be started, was cancelled, any of its concept types failed
+// or it didn't succeed in publishing its files.
+func runExportCommand(fullExporter *export.FullExporter, types string, supported []string, options export.Options, out io.Writer) int {
+	candidates, err := parseConceptTypes(types, supported)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return 1
+	}
+	if !fullExporter.IsSupportedFormat(options.Format) {
+		fmt.Fprintf(out, "Unsupported format %v\n", options.Format)
+		return 1
+	}
+	if options.Compression != "" && !export.IsSupportedCompression(options.Compression) {
+		fmt.Fprintf(out, "Unsupported compression %v\n", options.Compression)
+		return 1
+	}
+
+	job, err := fullExporter.TryCreateJob(candidates, options, "")
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return 1
+	}
+	fmt.Fprintf(out, "Export %v started for %v\n", job.ID, strings.Join(candidates, ", "))
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	done := make(chan struct{})
+	go func() {
+		fullExporter.RunFullExport(job, transactionidutils.NewTransactionID())
+		close(done)
+	}()
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			current := fullExporter.GetCurrentJob()
+			printProgress(out, &current)
+		case <-signals:
+			fmt.Fprintf(out, "Cancelling export %v\n", job.ID)
+			_, _ = fullExporter.CancelJob(job.ID)
+		case <-done:
+			fullExporter.WaitForNotifications()
+			result, _ := fullExporter.GetJob(job.ID)
+			printProgress(out, &result)
+			return exportExitCode(out, &result, len(candidates))
+		}
+	}
+}
+
+// parseConceptTypes returns the comma separated concept types, skipping the empty ones, or all the supported ones if there is none
+func parseConceptTypes(types string, supported []string) ([]string, error) {
+	var candidates, unsupported []string
+	for _, cType := range strings.Split(types, ",") {
+		cType = strings.TrimSpace(cType)
+		if cType == "" {
+			continue
+		}
+		found := false
+		for _, s := range supported {
+			if cType == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unsupported = append(unsupported, cType)
+			continue
+		}
+		candidates = append(candidates, cType)
+	}
+	if len(unsupported) != 0 {
+		return nil, fmt.Errorf("unsupported concept types %v, supported ones are %v", unsupported, supported)
+	}
+	if len(candidates) == 0 {
+		return supported, nil
+	}
+	return candidates, nil
+}
+
+func printProgress(out io.Writer, job *export.Job) {
+	for _, w := range job.Workers {
+		fmt.Fprintf(out, "%-15s %-10s %d concepts", w.ConceptType, w.Status, w.Progress)
+		if msg := strings.TrimSpace(w.ErrorMessage); msg != "" {
+			fmt.Fprintf(out, " error: %v", msg)
+		}
+		fmt.Fprintln(out)
+	}
+}
+
+func exportExitCode(out io.Writer, job *export.Job, nrOfCandidates int) int {
+	if msg := strings.TrimSpace(job.ErrorMessage); msg != "" {
+		fmt.Fprintf(out, "Export %v: %v\n", job.ID, msg)
+	}
+	switch {
+	case job.Status == concept.CANCELLED:
+		fmt.Fprintf(out, "Export %v was cancelled\n", job.ID)
+		return 1
+	case len(job.Failed) != 0:
+		fmt.Fprintf(out, "Export %v failed for %v\n", job.ID, strings.Join(job.Failed, ", "))
+		return 1
+	case len(job.Workers) != nrOfCandidates:
+		fmt.Fprintf(out, "Export %v could not be started\n", job.ID)
+		return 1
+	case strings.TrimSpace(job.ErrorMessage) != "" || !job.Succeeded:
+		fmt.Fprintf(out, "Export %v did not succeed\n", job.ID)
+		return 1
+	case !job.DryRun && len(job.Files) == 0:
+		fmt.Fprintf(out, "Export %v published no files\n", job.ID)
+		return 1
+	}
+	if job.DryRun {
+		printDryRunFiles(out, job)
+		return 0
+	}
+	fmt.Fprintf(out, "Export %v finished, files: %v\n", job.ID, strings.Join(job.Files, ", "))
+	return 0
+}
+
+func printDryRunFiles(out io.Writer, job *export.Job) {
+	fmt.Fprintf(out, "Dry run %v finished, nothing was uploaded\n", job.ID)
+	for _, f := range job.DryRunFiles {
+		fmt.Fprintf(out, "%-30s %d rows %d bytes\n", f.Name, f.Rows, f.Bytes)
+		for _, line := range f.Sample {
+			fmt.Fprintf(out, "    %v\n", line)
+		}
+	}
+}
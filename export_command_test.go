@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/concept-exporter/concept"
+	"github.com/Financial-Times/concept-exporter/db"
+	"github.com/Financial-Times/concept-exporter/export"
+	logger "github.com/Financial-Times/go-logger/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubInquirer reads the given concepts for every concept type
+type stubInquirer struct {
+	concepts map[string][]db.Concept
+}
+
+func (s *stubInquirer) Inquire(ctx context.Context, candidates []string, opts db.ReadOptions, tid string) []*concept.Worker {
+	var workers []*concept.Worker
+	for _, cType := range candidates {
+		worker := &concept.Worker{ConceptType: cType, Errch: make(chan error, 2), ConceptCh: make(chan db.Concept), Status: concept.STARTING}
+		workers = append(workers, worker)
+		go func(w *concept.Worker) {
+			defer close(w.Errch)
+			for _, c := range s.concepts[w.ConceptType] {
+				w.ConceptCh <- c
+			}
+			close(w.ConceptCh)
+		}(worker)
+	}
+	return workers
+}
+
+var testConcepts = map[string][]db.Concept{
+	"Brand": {{ID: "http://api.ft.com/things/1", UUID: "1", PrefLabel: "FT"}},
+	"Topic": {{ID: "http://api.ft.com/things/2", UUID: "2", PrefLabel: "Brexit"}},
+}
+
+func TestRunExportCommand(t *testing.T) {
+	dir := t.TempDir()
+	log := logger.NewUPPLogger("Test", "PANIC")
+	fe := export.NewFullExporter(1, 1, &concept.FileUpdater{Directory: dir}, &stubInquirer{concepts: testConcepts}, export.SupportedExporters(), log)
+
+	var out bytes.Buffer
+	code := runExportCommand(fe, "Brand", []string{"Brand", "Topic"}, export.Options{Format: export.CSVFormat}, &out)
+
+	assert.Equal(t, 0, code, out.String())
+	assert.Contains(t, out.String(), "files: Brand.csv, manifest.json")
+	content, err := os.ReadFile(filepath.Join(dir, "Brand.csv"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "http://api.ft.com/things/1,FT")
+}
+
+func TestRunExportCommandFailures(t *testing.T) {
+	log := logger.NewUPPLogger("Test", "PANIC")
+	tests := map[string]struct {
+		types    string
+		options  export.Options
+		expected string
+	}{
+		"unsupported concept type": {types: "Brand,Genre", options: export.Options{Format: export.CSVFormat}, expected: "unsupported concept types [Genre]"},
+		"unsupported format":       {types: "Brand", options: export.Options{Format: "xml"}, expected: "Unsupported format xml"},
+		"unsupported compression":  {types: "Brand", options: export.Options{Format: export.CSVFormat, Compression: "lzma"}, expected: "Unsupported compression lzma"},
+		// a delta export including unannotated concepts fails before reading any concept
+		"not started": {types: "Brand", options: export.Options{Format: export.CSVFormat, Since: new(time.Time), IncludeUnannotated: true}, expected: "could not be started"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fe := export.NewFullExporter(1, 1, &concept.FileUpdater{Directory: t.TempDir()}, &stubInquirer{concepts: testConcepts}, export.SupportedExporters(), log)
+
+			var out bytes.Buffer
+			code := runExportCommand(fe, test.types, []string{"Brand", "Topic"}, test.options, &out)
+
+			assert.Equal(t, 1, code)
+			assert.Contains(t, out.String(), test.expected)
+		})
+	}
+}
+
+func TestParseConceptTypes(t *testing.T) {
+	supported := []string{"Brand", "Topic", "Person"}
+	tests := map[string]struct {
+		types    string
+		expected []string
+	}{
+		"all":          {types: "", expected: supported},
+		"some":         {types: "Topic, Brand", expected: []string{"Topic", "Brand"}},
+		"empty entry":  {types: "Brand,,Topic", expected: []string{"Brand", "Topic"}},
+		"only commas":  {types: " , ", expected: supported},
+		"trailing one": {types: "Person,", expected: []string{"Person"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			candidates, err := parseConceptTypes(test.types, supported)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, candidates)
+		})
+	}
+
+	_, err := parseConceptTypes("Brand,Genre", supported)
+	assert.EqualError(t, err, "unsupported concept types [Genre], supported ones are [Brand Topic Person]")
+}
+
+func TestExportExitCode(t *testing.T) {
+	workers := []*concept.Worker{{ConceptType: "Brand"}}
+	tests := map[string]struct {
+		job      *export.Job
+		code     int
+		expected string
+	}{
+		"succeeded": {
+			job:      &export.Job{ID: "job_1", Succeeded: true, Workers: workers, Files: []string{"Brand.csv", "manifest.json"}},
+			expected: "Export job_1 finished, files: Brand.csv, manifest.json",
+		},
+		"dry run": {
+			job:      &export.Job{ID: "job_1", Succeeded: true, Workers: workers, Options: export.Options{DryRun: true}},
+			expected: "Dry run job_1 finished, nothing was uploaded",
+		},
+		"cancelled": {
+			job:      &export.Job{ID: "job_1", Status: concept.CANCELLED, Workers: workers},
+			code:     1,
+			expected: "Export job_1 was cancelled",
+		},
+		"failed concept type": {
+			job:      &export.Job{ID: "job_1", Failed: []string{"Brand"}, Workers: workers},
+			code:     1,
+			expected: "Export job_1 failed for Brand",
+		},
+		"not started": {
+			job:      &export.Job{ID: "job_1", ErrorMessage: " unsupported format xml"},
+			code:     1,
+			expected: "Export job_1 could not be started",
+		},
+		"manifest upload failed": {
+			job:      &export.Job{ID: "job_1", ErrorMessage: " manifest upload failed: S3 writer unavailable", Workers: workers, Files: []string{"Brand.csv"}},
+			code:     1,
+			expected: "Export job_1: manifest upload failed: S3 writer unavailable\nExport job_1 did not succeed",
+		},
+		"error message of a successful job": {
+			job:      &export.Job{ID: "job_1", Succeeded: true, ErrorMessage: " only Brand.csv were published", Workers: workers, Files: []string{"Brand.csv"}},
+			code:     1,
+			expected: "Export job_1 did not succeed",
+		},
+		"nothing published": {
+			job:      &export.Job{ID: "job_1", Succeeded: true, Workers: workers},
+			code:     1,
+			expected: "Export job_1 published no files",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			assert.Equal(t, test.code, exportExitCode(&out, test.job, 1))
+			assert.Contains(t, out.String(), test.expected)
+		})
+	}
+}
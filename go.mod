@@ -16,11 +16,14 @@ require (
 	github.com/Financial-Times/transactionid-utils-go v1.0.0
 	github.com/gorilla/mux v1.7.3
 	github.com/jawher/mow.cli v1.1.0
+	github.com/klauspost/compress v1.17.11
 	github.com/pborman/uuid v1.2.0
 	github.com/pkg/errors v0.8.1
 	github.com/rcrowley/go-metrics v0.0.0-20190826022208-cac0b30c2563
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/sethgrid/pester v0.0.0-20190127155807-68a33a018ad0
 	github.com/stretchr/testify v1.7.1
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
 
 require (
@@ -42,7 +45,6 @@ require (
 	golang.org/x/net v0.0.0-20210614182718-04defd469f4e // indirect
 	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
 
 replace gopkg.in/stretchr/testify.v1 => github.com/stretchr/testify v1.3.0
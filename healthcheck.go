@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/Financial-Times/concept-exporter/concept"
 	"github.com/Financial-Times/concept-exporter/db"
+	"github.com/Financial-Times/concept-exporter/export"
 	health "github.com/Financial-Times/go-fthealth/v1_1"
 	"github.com/Financial-Times/go-logger/v2"
 	"github.com/Financial-Times/service-status-go/gtg"
@@ -26,7 +28,9 @@ type healthConfig struct {
 	appName       string
 	port          string
 	s3Uploader    *concept.S3Updater
+	fileUpdater   *concept.FileUpdater
 	neoService    *db.NeoService
+	scheduler     *export.Scheduler
 	log           *logger.UPPLogger
 }
 
@@ -42,7 +46,10 @@ func newHealthService(config *healthConfig) *healthService {
 	svc := &healthService{config: config}
 	svc.checks = []health.Check{
 		svc.NeoCheck(),
-		svc.S3WriterCheck(),
+		svc.updaterCheck(),
+	}
+	if config.scheduler != nil {
+		svc.checks = append(svc.checks, svc.ScheduleCheck())
 	}
 	svc.client = &http.Client{
 		Transport: tr,
@@ -78,16 +85,54 @@ func (service *healthService) S3WriterCheck() health.Check {
 	}
 }
 
+func (service *healthService) OutputDirectoryCheck() health.Check {
+	return health.Check{
+		Name:             "CheckOutputDirectory",
+		BusinessImpact:   "No Business Impact.",
+		PanicGuide:       "https://runbooks.in.ft.com/concept-exporter",
+		Severity:         2,
+		TechnicalSummary: fmt.Sprintf("The service is unable to access the output directory (%s). Export won't work because of this", service.config.fileUpdater.Directory),
+		Checker: func() (string, error) {
+			return service.config.fileUpdater.CheckHealth()
+		},
+	}
+}
+
+func (service *healthService) ScheduleCheck() health.Check {
+	return health.Check{
+		Name:             "CheckScheduledExports",
+		BusinessImpact:   "No Business Impact.",
+		PanicGuide:       "https://runbooks.in.ft.com/concept-exporter",
+		Severity:         3,
+		TechnicalSummary: "There are no planned exports. Scheduled exports won't run because of this",
+		Checker: func() (string, error) {
+			runs := service.config.scheduler.NextRuns()
+			if len(runs) == 0 {
+				return "No planned exports", errors.New("the scheduler is not running")
+			}
+			return fmt.Sprintf("Next scheduled export of %v at %v", runs[0].Concepts, runs[0].Time.Format(time.RFC3339)), nil
+		},
+	}
+}
+
+// updaterCheck returns the check of the configured destination of the exported files
+func (service *healthService) updaterCheck() health.Check {
+	if service.config.fileUpdater != nil {
+		return service.OutputDirectoryCheck()
+	}
+	return service.S3WriterCheck()
+}
+
 func (service *healthService) GTG() gtg.Status {
-	s3WriterCheck := func() gtg.Status {
-		return service.gtgCheck(service.S3WriterCheck())
+	updaterCheck := func() gtg.Status {
+		return service.gtgCheck(service.updaterCheck())
 	}
 	neoCheck := func() gtg.Status {
 		return service.gtgCheck(service.NeoCheck())
 	}
 
 	return gtg.FailFastParallelCheck([]gtg.StatusChecker{
-		s3WriterCheck,
+		updaterCheck,
 		neoCheck,
 	})()
 }
@@ -11,6 +11,7 @@ import (
 
 	cmneo4j "github.com/Financial-Times/cm-neo4j-driver"
 	"github.com/Financial-Times/concept-exporter/concept"
+	"github.com/Financial-Times/concept-exporter/config"
 	"github.com/Financial-Times/concept-exporter/db"
 	"github.com/Financial-Times/concept-exporter/export"
 	"github.com/Financial-Times/concept-exporter/web"
@@ -53,6 +54,12 @@ func main() {
 		Desc:   "neo4j endpoint URL",
 		EnvVar: "NEO_URL",
 	})
+	neoPageSize := app.Int(cli.IntOpt{
+		Name:   "neo-page-size",
+		Value:  10000,
+		Desc:   "Number of concepts read from Neo4j with a single query",
+		EnvVar: "NEO_PAGE_SIZE",
+	})
 	s3WriterBaseURL := app.String(cli.StringOpt{
 		Name:   "s3WriterBaseURL",
 		Value:  "http://localhost:8080",
@@ -65,12 +72,72 @@ func main() {
 		Desc:   "Health URL to S3 writer endpoint",
 		EnvVar: "S3_WRITER_HEALTH_URL",
 	})
+	outputDir := app.String(cli.StringOpt{
+		Name:   "output-dir",
+		Value:  "",
+		Desc:   "Local directory to write the exported files to instead of sending them to the S3 writer",
+		EnvVar: "OUTPUT_DIR",
+	})
+	outputJobSubdirs := app.Bool(cli.BoolOpt{
+		Name:   "output-job-subdirs",
+		Value:  false,
+		Desc:   "Write the files of every job into a subdirectory of the output directory named after the job ID",
+		EnvVar: "OUTPUT_JOB_SUBDIRS",
+	})
+	atomicPublish := app.Bool(cli.BoolOpt{
+		Name:   "atomic-publish",
+		Value:  false,
+		Desc:   "Publish the files of a job all at once through its manifest, only if every concept type was exported successfully",
+		EnvVar: "ATOMIC_PUBLISH",
+	})
+	concurrentWorkers := app.Int(cli.IntOpt{
+		Name:   "concurrent-workers",
+		Value:  3,
+		Desc:   "Number of concept types read from Neo4j and exported at the same time",
+		EnvVar: "CONCURRENT_WORKERS",
+	})
+	jobHistorySize := app.Int(cli.IntOpt{
+		Name:   "job-history-size",
+		Value:  10,
+		Desc:   "Number of past export jobs kept in memory and returned by /jobs",
+		EnvVar: "JOB_HISTORY_SIZE",
+	})
+	schedule := app.String(cli.StringOpt{
+		Name:   "schedule",
+		Value:  "",
+		Desc:   "Cron expressions (UTC) of scheduled exports separated by semicolons, each optionally followed by a pipe and the comma separated concept types to export, e.g. \"0 2 * * *|Brand,Topic;0 3 * * 0\"",
+		EnvVar: "SCHEDULE",
+	})
+	webhookURLs := app.Strings(cli.StringsOpt{
+		Name:   "webhook-urls",
+		Value:  []string{},
+		Desc:   "URLs notified with a POST of the final state of every export job",
+		EnvVar: "WEBHOOK_URLS",
+	})
+	webhookSecret := app.String(cli.StringOpt{
+		Name:   "webhook-secret",
+		Value:  "",
+		Desc:   "Secret used to sign the webhook payloads with HMAC-SHA256, required with --webhook-urls",
+		EnvVar: "WEBHOOK_SECRET",
+	})
+	compression := app.String(cli.StringOpt{
+		Name:   "compression",
+		Value:  export.NoCompression,
+		Desc:   "Default compression of the exported files (none, gzip, zstd), overridable per job",
+		EnvVar: "COMPRESSION",
+	})
 	conceptTypes := app.Strings(cli.StringsOpt{
 		Name:   "conceptTypes",
 		Value:  []string{"Brand", "Topic", "Location", "Person", "Organisation"},
 		Desc:   "Concept types to support",
 		EnvVar: "CONCEPT_TYPES",
 	})
+	configFile := app.String(cli.StringOpt{
+		Name:   "config",
+		Value:  "",
+		Desc:   "YAML or JSON file defining the concept types to support with their queries and CSV columns, instead of --conceptTypes",
+		EnvVar: "CONFIG_FILE",
+	})
 	logLevel := app.String(cli.StringOpt{
 		Name:   "log-level",
 		Value:  "info",
@@ -87,9 +154,7 @@ func main() {
 	log := logger.NewUPPLogger(*appName, *logLevel)
 	driverLog := logger.NewUPPLogger(*appName+"-cmneo4j-driver", *dbDriverLogLevel)
 
-	app.Action = func() {
-		log.WithField("service_name", *appName).Info("Service started")
-
+	newServices := func(outputDir string) *services {
 		driver, err := cmneo4j.NewDefaultDriver(*neoURL, driverLog)
 		if err != nil {
 			log.WithError(err).Fatalf("Couldn't create a new driver")
@@ -101,32 +166,146 @@ func main() {
 				Timeout:   30 * time.Second,
 				KeepAlive: 30 * time.Second,
 			}).Dial,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 30 * time.Second,
+			IdleConnTimeout:       90 * time.Second,
 		}
+		// the S3 updater retries the uploads itself, pester would buffer the streamed files to retry them.
+		// An upload streams a whole file, which can take longer than any fixed timeout, so the client has none:
+		// the transport bounds connecting and waiting for the response, and the job context cancels the uploads.
 		c := &http.Client{
 			Transport: tr,
-			Timeout:   30 * time.Second,
 		}
-		client := pester.NewExtendedClient(c)
-		client.Backoff = pester.ExponentialBackoff
-		client.MaxRetries = 3
-		client.Concurrency = 1
 
-		uploader := &concept.S3Updater{Client: client, S3WriterBaseURL: *s3WriterBaseURL, S3WriterHealthURL: *s3WriterHealthURL}
-		neoService := db.NewNeoService(driver, *neoURL)
-		fullExporter := export.NewFullExporter(30, uploader, concept.NewNeoInquirer(neoService, log),
-			export.NewCsvExporter(), log)
+		svcs := &services{conceptTypes: *conceptTypes}
+		var uploader concept.Updater
+		if outputDir != "" {
+			log.Infof("Exported files are written to %v", outputDir)
+			svcs.fileUpdater = &concept.FileUpdater{Directory: outputDir, JobSubdirectories: *outputJobSubdirs}
+			uploader = svcs.fileUpdater
+		} else {
+			svcs.s3Uploader = &concept.S3Updater{Client: c, S3WriterBaseURL: *s3WriterBaseURL, S3WriterHealthURL: *s3WriterHealthURL,
+				MaxRetries: 3, Backoff: pester.ExponentialBackoff}
+			uploader = svcs.s3Uploader
+		}
+		svcs.neoService = db.NewNeoService(driver, *neoURL)
+		svcs.neoService.PageSize = *neoPageSize
+		svcs.fullExporter = export.NewFullExporter(*concurrentWorkers, *jobHistorySize, uploader, concept.NewNeoInquirer(svcs.neoService, *concurrentWorkers, log),
+			export.SupportedExporters(), log)
 
+		webhookClient := pester.NewExtendedClient(&http.Client{Transport: tr, Timeout: 10 * time.Second})
+		webhookClient.Backoff = pester.ExponentialBackoff
+		webhookClient.MaxRetries = 5
+		webhookClient.Concurrency = 1
+		if len(*webhookURLs) != 0 && *webhookSecret == "" {
+			log.Fatalf("The webhooks %v need --webhook-secret to sign the notifications", *webhookURLs)
+		}
+		svcs.fullExporter.Notifier = export.NewWebhookNotifier(webhookClient, *webhookURLs, *webhookSecret, log)
+		svcs.fullExporter.AtomicPublish = *atomicPublish
+		svcs.fullExporter.Compression = *compression
+		svcs.fullExporter.Relationships = svcs.neoService
+		svcs.fullExporter.Concordances = svcs.neoService
+		if *configFile != "" {
+			cfg, err := config.Load(*configFile)
+			if err != nil {
+				log.WithError(err).Fatal("Couldn't load the config file")
+			}
+			log.Infof("Concept types are defined by %v", *configFile)
+			svcs.conceptTypes = cfg.Names()
+			svcs.neoService.Queries = cfg.Queries()
+			svcs.fullExporter.CSVColumns = cfg.CSVColumns()
+		}
+		if err = svcs.neoService.RegisterLabels(svcs.conceptTypes...); err != nil {
+			log.WithError(err).Fatal("Couldn't register the concept types")
+		}
+		return svcs
+	}
+
+	app.Action = func() {
+		log.WithField("service_name", *appName).Info("Service started")
+
+		svcs := newServices(*outputDir)
+		var scheduler *export.Scheduler
+		if *schedule != "" {
+			entries, err := export.ParseSchedule(*schedule, svcs.conceptTypes)
+			if err != nil {
+				log.WithError(err).Fatal("Couldn't parse the export schedule")
+			}
+			scheduler = export.NewScheduler(svcs.fullExporter, entries, log)
+			scheduler.Start()
+			defer scheduler.Stop()
+		}
 		healthService := newHealthService(
 			&healthConfig{
 				appSystemCode: *appSystemCode,
 				appName:       *appName,
 				port:          *port,
-				s3Uploader:    uploader,
-				neoService:    neoService,
+				s3Uploader:    svcs.s3Uploader,
+				fileUpdater:   svcs.fileUpdater,
+				neoService:    svcs.neoService,
+				scheduler:     scheduler,
 				log:           log,
 			})
-		serveEndpoints(*appSystemCode, *appName, *port, web.NewRequestHandler(fullExporter, *conceptTypes, log), healthService, log)
+		serveEndpoints(*appSystemCode, *appName, *port, web.NewRequestHandler(svcs.fullExporter, scheduler, svcs.conceptTypes, log), healthService, log)
 	}
+
+	app.Command("export", "Runs a single export and exits, without starting the HTTP server", func(cmd *cli.Cmd) {
+		types := cmd.String(cli.StringOpt{
+			Name:  "types",
+			Value: "",
+			Desc:  "Comma separated concept types to export, e.g. Brand,Person. All supported concept types are exported if empty",
+		})
+		out := cmd.String(cli.StringOpt{
+			Name:  "out",
+			Value: "",
+			Desc:  "Directory to write the exported files to. Defaults to --output-dir, or to the S3 writer if that is empty too",
+		})
+		format := cmd.String(cli.StringOpt{
+			Name:  "format",
+			Value: export.CSVFormat,
+			Desc:  "Output format (csv, jsonl)",
+		})
+		dryRun := cmd.Bool(cli.BoolOpt{
+			Name:  "dry-run",
+			Value: false,
+			Desc:  "Export the concepts without writing or uploading the files, printing their sizes and first lines instead",
+		})
+		relationships := cmd.Bool(cli.BoolOpt{
+			Name:  "relationships",
+			Value: false,
+			Desc:  "Export the relationships between the exported concepts into a separate relationships file too",
+		})
+		concordance := cmd.Bool(cli.BoolOpt{
+			Name:  "concordance",
+			Value: false,
+			Desc:  "Export the identifiers the exported concepts have in other systems into a separate concordance file too",
+		})
+		annotationStats := cmd.Bool(cli.BoolOpt{
+			Name:  "annotation-stats",
+			Value: false,
+			Desc:  "Export how many times every concept annotates content with each predicate and the dates of its first and last annotations",
+		})
+		includeUnannotated := cmd.Bool(cli.BoolOpt{
+			Name:  "include-unannotated",
+			Value: false,
+			Desc:  "Export every concept of the concept types, with an annotated column telling whether it annotates any content",
+		})
+		exportCompression := cmd.String(cli.StringOpt{
+			Name:  "compression",
+			Value: "",
+			Desc:  "Compression of the exported files (none, gzip, zstd). Defaults to --compression of the service",
+		})
+
+		cmd.Action = func() {
+			outputDirectory := *out
+			if outputDirectory == "" {
+				outputDirectory = *outputDir
+			}
+			svcs := newServices(outputDirectory)
+			cli.Exit(runExportCommand(svcs.fullExporter, *types, svcs.conceptTypes, export.Options{Format: *format, Compression: *exportCompression, DryRun: *dryRun, Relationships: *relationships, Concordance: *concordance, AnnotationStats: *annotationStats, IncludeUnannotated: *includeUnannotated}, os.Stdout))
+		}
+	})
+
 	err := app.Run(os.Args)
 	if err != nil {
 		log.Errorf("App could not start, error=[%s]\n", err)
@@ -134,6 +313,15 @@ func main() {
 	}
 }
 
+type services struct {
+	fullExporter *export.FullExporter
+	neoService   *db.NeoService
+	s3Uploader   *concept.S3Updater
+	fileUpdater  *concept.FileUpdater
+	// conceptTypes are the supported concept types, from the config file if any
+	conceptTypes []string
+}
+
 func serveEndpoints(appSystemCode string, appName string, port string, requestHandler *web.RequestHandler,
 	healthService *healthService, log *logger.UPPLogger) {
 
@@ -148,6 +336,9 @@ func serveEndpoints(appSystemCode string, appName string, port string, requestHa
 
 	servicesRouter.HandleFunc("/export", requestHandler.Export).Methods(http.MethodPost)
 	servicesRouter.HandleFunc("/job", requestHandler.GetJob).Methods(http.MethodGet)
+	servicesRouter.HandleFunc("/jobs", requestHandler.GetJobs).Methods(http.MethodGet)
+	servicesRouter.HandleFunc("/jobs/{id}", requestHandler.GetJobByID).Methods(http.MethodGet)
+	servicesRouter.HandleFunc("/jobs/{id}", requestHandler.CancelJob).Methods(http.MethodDelete)
 
 	var monitoringRouter http.Handler = servicesRouter
 	monitoringRouter = httphandlers.TransactionAwareRequestLoggingHandler(log, monitoringRouter)
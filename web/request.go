@@ -0,0 +1,204 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Financial-Times/concept-exporter/export"
+)
+
+// exportRequest is the JSON body of /export, all its fields are optional
+type exportRequest struct {
+	ConceptTypes       conceptTypeList     `json:"conceptTypes"`
+	Delta              bool                `json:"delta"`
+	Since              string              `json:"since"`
+	Format             string              `json:"format"`
+	Compression        string              `json:"compression"`
+	CallbackURL        string              `json:"callbackUrl"`
+	CSVColumns         map[string][]string `json:"csvColumns"`
+	Destination        string              `json:"destination"`
+	DryRun             bool                `json:"dryRun"`
+	Relationships      bool                `json:"relationships"`
+	Concordance        bool                `json:"concordance"`
+	AnnotationStats    bool                `json:"annotationStats"`
+	MinAnnotations     int                 `json:"minAnnotations"`
+	AnnotatedSince     string              `json:"annotatedSince"`
+	ExcludeDeprecated  bool                `json:"excludeDeprecated"`
+	IncludeUnannotated bool                `json:"includeUnannotated"`
+}
+
+// conceptTypeList is a JSON array of concept types, or a string of concept types separated by spaces as sent by older clients
+type conceptTypeList []string
+
+func (l *conceptTypeList) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*l = list
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return &fieldError{Field: "conceptTypes", Message: "is not an array of strings"}
+	}
+	*l = strings.Fields(s)
+	return nil
+}
+
+// fieldError is the reason a field of the request is invalid
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *fieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// requestErrors is the body of the responses to invalid requests
+type requestErrors struct {
+	Message string       `json:"message"`
+	Errors  []fieldError `json:"errors"`
+}
+
+func writeRequestErrors(writer http.ResponseWriter, errs []fieldError) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(writer).Encode(requestErrors{Message: "Invalid export request", Errors: errs})
+}
+
+// decodeExportRequest decodes the body, an empty one being an empty request
+func decodeExportRequest(body io.Reader) (exportRequest, *fieldError) {
+	var req exportRequest
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return req, &fieldError{Field: "body", Message: "can't be read"}
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return req, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(&req); err != nil {
+		return req, decodingError(err)
+	}
+	if dec.More() {
+		return req, &fieldError{Field: "body", Message: "has more than one JSON value"}
+	}
+	return req, nil
+}
+
+func decodingError(err error) *fieldError {
+	var fieldErr *fieldError
+	if errors.As(err, &fieldErr) {
+		return fieldErr
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return &fieldError{Field: typeErr.Field, Message: fmt.Sprintf("is not a valid %v", typeErr.Type)}
+	}
+	// the decoder has no typed error for unknown fields
+	if name, found := strings.CutPrefix(err.Error(), "json: unknown field "); found {
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			name = unquoted
+		}
+		return &fieldError{Field: name, Message: "is not a known field"}
+	}
+	return &fieldError{Field: "body", Message: "is not a valid JSON object: " + err.Error()}
+}
+
+// options validates the export options of the request, the concept types excepted, returning an error for every invalid field
+func (req exportRequest) options(exporter *export.FullExporter, candidates []string) (export.Options, []fieldError) {
+	options := export.Options{
+		Format:             req.Format,
+		Compression:        req.Compression,
+		CSVColumns:         req.CSVColumns,
+		Destination:        req.Destination,
+		DryRun:             req.DryRun,
+		Relationships:      req.Relationships,
+		Concordance:        req.Concordance,
+		AnnotationStats:    req.AnnotationStats,
+		MinAnnotations:     req.MinAnnotations,
+		ExcludeDeprecated:  req.ExcludeDeprecated,
+		IncludeUnannotated: req.IncludeUnannotated,
+	}
+	var errs []fieldError
+	if req.Since != "" {
+		since, err := time.Parse(time.RFC3339, req.Since)
+		if err != nil {
+			errs = append(errs, fieldError{Field: "since", Message: "is not an RFC3339 timestamp"})
+		} else {
+			options.Since = &since
+		}
+	}
+	if req.IncludeUnannotated && (req.Delta || req.Since != "") {
+		errs = append(errs, fieldError{Field: "includeUnannotated", Message: "can't be combined with a delta export"})
+	}
+	if req.MinAnnotations < 0 {
+		errs = append(errs, fieldError{Field: "minAnnotations", Message: "is negative"})
+	}
+	if req.AnnotatedSince != "" {
+		annotatedSince, err := time.Parse(time.RFC3339, req.AnnotatedSince)
+		if err != nil {
+			errs = append(errs, fieldError{Field: "annotatedSince", Message: "is not an RFC3339 timestamp"})
+		} else {
+			options.AnnotatedSince = &annotatedSince
+		}
+	}
+	if req.Format != "" && !exporter.IsSupportedFormat(req.Format) {
+		errs = append(errs, fieldError{Field: "format", Message: fmt.Sprintf("%v is not supported", req.Format)})
+	}
+	if req.Compression != "" && !export.IsSupportedCompression(req.Compression) {
+		errs = append(errs, fieldError{Field: "compression", Message: fmt.Sprintf("%v is not supported", req.Compression)})
+	}
+	if req.CallbackURL != "" {
+		u, err := url.Parse(req.CallbackURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fieldError{Field: "callbackUrl", Message: "is not an absolute HTTP URL"})
+		} else {
+			options.CallbackURL = req.CallbackURL
+		}
+	}
+	if err := validateCSVColumns(options, candidates); err != nil {
+		errs = append(errs, fieldError{Field: "csvColumns", Message: err.Error()})
+	}
+	if req.Destination != "" {
+		if err := export.ValidateDestination(req.Destination); err != nil {
+			errs = append(errs, fieldError{Field: "destination", Message: "is not a relative path of letters, digits, dots, dashes and underscores"})
+		}
+	}
+	return options, errs
+}
+
+// validateCSVColumns checks that the requested CSV columns are supported and belong to exported concept types
+func validateCSVColumns(options export.Options, candidates []string) error {
+	if len(options.CSVColumns) == 0 {
+		return nil
+	}
+	if options.Format != "" && options.Format != export.CSVFormat {
+		return fmt.Errorf("can't be used with the %v format", options.Format)
+	}
+	for cType, columns := range options.CSVColumns {
+		if !slices.Contains(candidates, cType) {
+			return fmt.Errorf("has columns of %v which is not exported", cType)
+		}
+		if len(columns) == 0 {
+			return fmt.Errorf("has no columns for %v", cType)
+		}
+		for _, column := range columns {
+			if !export.IsSupportedCSVColumn(column) {
+				return fmt.Errorf("has the unsupported column %v for %v, the supported ones are %v", column, cType, strings.Join(export.SupportedCSVColumns(), ", "))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/concept-exporter/concept"
+	"github.com/Financial-Times/concept-exporter/export"
+	logger "github.com/Financial-Times/go-logger/v2"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var supportedConceptTypes = []string{"Brand", "Topic", "Location", "Person", "Organisation"}
+
+func TestDecodeExportRequest(t *testing.T) {
+	tests := map[string]struct {
+		body     string
+		expected exportRequest
+	}{
+		"empty body": {
+			body: "",
+		},
+		"concept types array": {
+			body:     `{"conceptTypes": ["Brand", "Topic"], "format": "jsonl", "delta": true, "dryRun": true}`,
+			expected: exportRequest{ConceptTypes: conceptTypeList{"Brand", "Topic"}, Format: "jsonl", Delta: true, DryRun: true},
+		},
+		"concept types string": {
+			body:     `{"conceptTypes": "Brand  Topic"}`,
+			expected: exportRequest{ConceptTypes: conceptTypeList{"Brand", "Topic"}},
+		},
+		"all options": {
+			body: `{"since": "2019-10-01T02:00:00Z", "compression": "gzip", "callbackUrl": "https://example.com/done",
+				"csvColumns": {"Brand": ["uuid"]}, "destination": "reexport", "relationships": true, "concordance": true, "annotationStats": true,
+				"minAnnotations": 5, "annotatedSince": "2019-01-01T00:00:00Z", "excludeDeprecated": true}`,
+			expected: exportRequest{Since: "2019-10-01T02:00:00Z", Compression: "gzip", CallbackURL: "https://example.com/done",
+				CSVColumns: map[string][]string{"Brand": {"uuid"}}, Destination: "reexport", Relationships: true, Concordance: true, AnnotationStats: true,
+				MinAnnotations: 5, AnnotatedSince: "2019-01-01T00:00:00Z", ExcludeDeprecated: true},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, err := decodeExportRequest(strings.NewReader(test.body))
+			require.Nil(t, err)
+			assert.Equal(t, test.expected, req)
+		})
+	}
+}
+
+func TestDecodeExportRequest_Invalid(t *testing.T) {
+	tests := map[string]struct {
+		body     string
+		expected fieldError
+	}{
+		"malformed": {
+			body:     `{"conceptTypes": ["Brand"`,
+			expected: fieldError{Field: "body", Message: "is not a valid JSON object: unexpected EOF"},
+		},
+		"not an object": {
+			body:     `["Brand"]`,
+			expected: fieldError{Field: "body", Message: "is not a valid JSON object: json: cannot unmarshal array into Go value of type web.exportRequest"},
+		},
+		"unknown field": {
+			body:     `{"conceptType": ["Brand"]}`,
+			expected: fieldError{Field: "conceptType", Message: "is not a known field"},
+		},
+		"concept types of the wrong type": {
+			body:     `{"conceptTypes": [1, 2]}`,
+			expected: fieldError{Field: "conceptTypes", Message: "is not an array of strings"},
+		},
+		"option of the wrong type": {
+			body:     `{"delta": "yes"}`,
+			expected: fieldError{Field: "delta", Message: "is not a valid bool"},
+		},
+		"more values": {
+			body:     `{} {}`,
+			expected: fieldError{Field: "body", Message: "has more than one JSON value"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := decodeExportRequest(strings.NewReader(test.body))
+			require.NotNil(t, err)
+			assert.Equal(t, test.expected, *err)
+		})
+	}
+}
+
+func TestExportRequestOptions(t *testing.T) {
+	fe := export.NewFullExporter(1, 1, nil, nil, export.SupportedExporters(), logger.NewUPPLogger("Test", "PANIC"))
+
+	req := exportRequest{Since: "2019-10-01T02:00:00Z", Format: "csv", Compression: "zstd", CallbackURL: "https://example.com/done",
+		CSVColumns: map[string][]string{"Brand": {"uuid"}}, Destination: "reexport/2019",
+		MinAnnotations: 5, AnnotatedSince: "2019-01-01T00:00:00Z", ExcludeDeprecated: true}
+	options, errs := req.options(fe, []string{"Brand"})
+	require.Empty(t, errs)
+	since := time.Date(2019, 10, 1, 2, 0, 0, 0, time.UTC)
+	annotatedSince := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, export.Options{Format: "csv", Since: &since, CallbackURL: "https://example.com/done", Compression: "zstd",
+		CSVColumns: map[string][]string{"Brand": {"uuid"}}, Destination: "reexport/2019",
+		MinAnnotations: 5, AnnotatedSince: &annotatedSince, ExcludeDeprecated: true}, options)
+
+	req = exportRequest{Since: "yesterday", Format: "xml", Compression: "brotli", CallbackURL: "/done",
+		CSVColumns: map[string][]string{"Topic": {"uuid"}}, Destination: "../reexport", MinAnnotations: -1, AnnotatedSince: "last year"}
+	_, errs = req.options(fe, []string{"Brand"})
+	var fields []string
+	for _, err := range errs {
+		fields = append(fields, err.Field)
+	}
+	assert.Equal(t, []string{"since", "minAnnotations", "annotatedSince", "format", "compression", "callbackUrl", "csvColumns", "destination"}, fields)
+}
+
+func TestExport_InvalidRequest(t *testing.T) {
+	fe := export.NewFullExporter(1, 1, nil, nil, export.SupportedExporters(), logger.NewUPPLogger("Test", "PANIC"))
+	handler := NewRequestHandler(fe, nil, supportedConceptTypes, logger.NewUPPLogger("Test", "PANIC"))
+
+	tests := map[string]struct {
+		body     string
+		expected []fieldError
+	}{
+		"unknown field": {
+			body:     `{"types": ["Brand"]}`,
+			expected: []fieldError{{Field: "types", Message: "is not a known field"}},
+		},
+		"unsupported concept types": {
+			body:     `{"conceptTypes": ["Genre"]}`,
+			expected: []fieldError{{Field: "conceptTypes", Message: "has no supported concept types"}},
+		},
+		"some unsupported concept types": {
+			body:     `{"conceptTypes": ["Brand", "Genre", "Topic", "Section"]}`,
+			expected: []fieldError{{Field: "conceptTypes", Message: "has unsupported concept types Genre, Section"}},
+		},
+		"invalid options": {
+			body: `{"conceptTypes": ["Brand"], "format": "xml", "since": "yesterday"}`,
+			expected: []fieldError{
+				{Field: "since", Message: "is not an RFC3339 timestamp"},
+				{Field: "format", Message: "xml is not supported"},
+			},
+		},
+		"unannotated delta": {
+			body:     `{"conceptTypes": ["Brand"], "delta": true, "includeUnannotated": true}`,
+			expected: []fieldError{{Field: "includeUnannotated", Message: "can't be combined with a delta export"}},
+		},
+		"delta without history": {
+			body:     `{"conceptTypes": ["Brand"], "delta": true}`,
+			expected: []fieldError{{Field: "delta", Message: "has no successful job to export the changes since"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.Export(rec, httptest.NewRequest(http.MethodPost, "/export", strings.NewReader(test.body)))
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			var body requestErrors
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, test.expected, body.Errors)
+			assert.Empty(t, fe.GetJobs(), "no job should be created")
+		})
+	}
+}
+
+func TestExport_JobAlreadyStarting(t *testing.T) {
+	fe := export.NewFullExporter(1, 1, nil, nil, export.SupportedExporters(), logger.NewUPPLogger("Test", "PANIC"))
+	handler := NewRequestHandler(fe, nil, supportedConceptTypes, logger.NewUPPLogger("Test", "PANIC"))
+	starting, err := fe.TryCreateJob([]string{"Brand"}, export.Options{}, "")
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	handler.Export(rec, httptest.NewRequest(http.MethodPost, "/export", strings.NewReader(`{"conceptTypes": ["Topic"]}`)))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, starting.ID, fe.GetCurrentJob().ID)
+}
+
+// newJobHandler returns the handler of an exporter without exporters, whose jobs finish as soon as they run
+func newJobHandler() (*RequestHandler, *export.FullExporter) {
+	fe := export.NewFullExporter(1, 2, nil, nil, map[string]export.NewExporterFunc{}, logger.NewUPPLogger("Test", "PANIC"))
+	return NewRequestHandler(fe, nil, supportedConceptTypes, logger.NewUPPLogger("Test", "PANIC")), fe
+}
+
+// runJob creates a job of the exporter and runs it until it is over
+func runJob(t *testing.T, fe *export.FullExporter, candidates []string) *export.Job {
+	job, err := fe.TryCreateJob(candidates, export.Options{}, "")
+	require.NoError(t, err)
+	fe.RunFullExport(job, "tid_1234")
+	return job
+}
+
+func TestGetJobs(t *testing.T) {
+	handler, fe := newJobHandler()
+
+	rec := httptest.NewRecorder()
+	handler.GetJobs(rec, httptest.NewRequest(http.MethodGet, "/jobs", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, "[]", rec.Body.String())
+
+	first := runJob(t, fe, []string{"Brand"})
+	second := runJob(t, fe, []string{"Topic"})
+
+	rec = httptest.NewRecorder()
+	handler.GetJobs(rec, httptest.NewRequest(http.MethodGet, "/jobs", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var jobs []export.Job
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &jobs))
+	require.Len(t, jobs, 2)
+	assert.Equal(t, second.ID, jobs[0].ID, "the most recent job should be first")
+	assert.Equal(t, []string{"Topic"}, jobs[0].Concepts)
+	assert.Equal(t, first.ID, jobs[1].ID)
+}
+
+func TestGetJobByID(t *testing.T) {
+	handler, fe := newJobHandler()
+	job := runJob(t, fe, []string{"Brand"})
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/jobs/"+job.ID, nil), map[string]string{"id": job.ID})
+	rec := httptest.NewRecorder()
+	handler.GetJobByID(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var body export.Job
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, job.ID, body.ID)
+	assert.Equal(t, []string{"Brand"}, body.Concepts)
+	assert.Equal(t, concept.FINISHED, body.Status)
+}
+
+func TestGetJobByID_NotFound(t *testing.T) {
+	handler, fe := newJobHandler()
+	runJob(t, fe, []string{"Brand"})
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/jobs/job_unknown", nil), map[string]string{"id": "job_unknown"})
+	rec := httptest.NewRecorder()
+	handler.GetJobByID(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Job job_unknown not found")
+}
+
+func cancelJob(handler *RequestHandler, id string) *httptest.ResponseRecorder {
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/jobs/"+id, nil), map[string]string{"id": id})
+	rec := httptest.NewRecorder()
+	handler.CancelJob(rec, req)
+	return rec
+}
+
+func TestCancelJob(t *testing.T) {
+	handler, fe := newJobHandler()
+	job, err := fe.TryCreateJob([]string{"Brand"}, export.Options{}, "")
+	require.NoError(t, err)
+
+	rec := cancelJob(handler, job.ID)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var body export.Job
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, job.ID, body.ID)
+
+	// the cancelled job ends as soon as it runs
+	fe.RunFullExport(job, "tid_1234")
+	result, _ := fe.GetJob(job.ID)
+	assert.Equal(t, concept.CANCELLED, result.Status)
+}
+
+func TestCancelJob_NotFound(t *testing.T) {
+	handler, fe := newJobHandler()
+	runJob(t, fe, []string{"Brand"})
+
+	rec := cancelJob(handler, "job_unknown")
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Job job_unknown not found")
+}
+
+func TestCancelJob_Finished(t *testing.T) {
+	handler, fe := newJobHandler()
+	job := runJob(t, fe, []string{"Brand"})
+
+	rec := cancelJob(handler, job.ID)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "is not running, its status is Finished")
+	result, _ := fe.GetJob(job.ID)
+	assert.Equal(t, concept.FINISHED, result.Status, "a finished job should stay finished")
+}
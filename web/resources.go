@@ -2,37 +2,52 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 
-	"io/ioutil"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/Financial-Times/concept-exporter/export"
 	logger "github.com/Financial-Times/go-logger/v2"
 	transactionidutils "github.com/Financial-Times/transactionid-utils-go"
+	"github.com/gorilla/mux"
 )
 
 type RequestHandler struct {
 	Exporter     *export.FullExporter
+	Scheduler    *export.Scheduler
 	ConceptTypes []string
 	Log          *logger.UPPLogger
 }
 
-func NewRequestHandler(fullExporter *export.FullExporter, conceptTypes []string, log *logger.UPPLogger) *RequestHandler {
+// NewRequestHandler returns the handler of the export endpoints, the scheduler is nil if exports are not scheduled
+func NewRequestHandler(fullExporter *export.FullExporter, scheduler *export.Scheduler, conceptTypes []string, log *logger.UPPLogger) *RequestHandler {
 	return &RequestHandler{
 		Exporter:     fullExporter,
+		Scheduler:    scheduler,
 		ConceptTypes: conceptTypes,
 		Log:          log,
 	}
 }
 
+// currentJob is the current job with the next planned exports
+type currentJob struct {
+	*export.Job
+	NextScheduledRuns []export.ScheduledRun `json:"NextScheduledRuns,omitempty"`
+}
+
 func (handler *RequestHandler) GetJob(writer http.ResponseWriter, request *http.Request) {
 	writer.Header().Add("Content-Type", "application/json")
 
 	job := handler.Exporter.GetCurrentJob()
+	resp := currentJob{Job: &job}
+	if handler.Scheduler != nil {
+		resp.NextScheduledRuns = handler.Scheduler.NextRuns()
+	}
 
-	err := json.NewEncoder(writer).Encode(&job)
+	err := json.NewEncoder(writer).Encode(&resp)
 	if err != nil {
 		msg := fmt.Sprintf(`Failed to write job %v to response writer: "%v"`, job.ID, err)
 		tid := transactionidutils.GetTransactionIDFromRequest(request)
@@ -42,86 +57,128 @@ func (handler *RequestHandler) GetJob(writer http.ResponseWriter, request *http.
 	}
 }
 
-func (handler *RequestHandler) Export(writer http.ResponseWriter, request *http.Request) {
-	tid := transactionidutils.GetTransactionIDFromRequest(request)
+func (handler *RequestHandler) GetJobs(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Add("Content-Type", "application/json")
 
-	if handler.Exporter.IsRunningJob() {
-		http.Error(writer, "There are already running export jobs. Please wait them to finish", http.StatusBadRequest)
+	jobs := handler.Exporter.GetJobs()
+
+	err := json.NewEncoder(writer).Encode(&jobs)
+	if err != nil {
+		tid := transactionidutils.GetTransactionIDFromRequest(request)
+		handler.Log.WithTransactionID(tid).WithError(err).Warn("Failed to write jobs to response writer")
 		return
 	}
-	candidates, errMsg := handler.getCandidateConceptTypes(request, tid)
-	if len(candidates) == 0 {
-		http.Error(writer, "No valid candidate concept types in the request", http.StatusBadRequest)
+}
+
+func (handler *RequestHandler) GetJobByID(writer http.ResponseWriter, request *http.Request) {
+	id := mux.Vars(request)["id"]
+	job, found := handler.Exporter.GetJob(id)
+	if !found {
+		http.Error(writer, fmt.Sprintf("Job %v not found", id), http.StatusNotFound)
 		return
 	}
-	job := handler.Exporter.CreateJob(candidates, errMsg)
-	go handler.Exporter.RunFullExport(tid)
-	writer.WriteHeader(http.StatusAccepted)
-	writer.Header().Add("Content-Type", "application/json")
 
+	writer.Header().Add("Content-Type", "application/json")
 	err := json.NewEncoder(writer).Encode(&job)
 	if err != nil {
 		msg := fmt.Sprintf(`Failed to write job %v to response writer: "%v"`, job.ID, err)
-		handler.Log.WithTransactionID(tid).Warnf(msg)
+		tid := transactionidutils.GetTransactionIDFromRequest(request)
+		handler.Log.WithTransactionID(tid).Warn(msg)
 		fmt.Fprintf(writer, "{\"ID\": \"%v\"}", job.ID)
 		return
 	}
 }
 
-func (handler *RequestHandler) getCandidateConceptTypes(request *http.Request, tid string) (candidates []string, errMsg string) {
-	candidates = extractCandidateConceptTypesFromRequest(request, handler.Log.WithTransactionID(tid))
-	if len(candidates) != 0 {
-		var unsupported []string
-		for i, cand := range candidates {
-			found := false
-			for _, cType := range handler.ConceptTypes {
-				if cand == cType {
-					found = true
-					break
-				}
-			}
-			if !found {
-				unsupported = append(unsupported, cand)
-				candidates = append(candidates[:i], candidates[i+1:]...)
-			}
-		}
-		if len(unsupported) != 0 {
-			errMsg = fmt.Sprintf("There are unsupported concept types within the candidates: %v", unsupported)
-		}
-		if len(candidates) == 0 {
-			return
-		}
+func (handler *RequestHandler) CancelJob(writer http.ResponseWriter, request *http.Request) {
+	id := mux.Vars(request)["id"]
+	job, err := handler.Exporter.CancelJob(id)
+	if errors.Is(err, export.ErrJobNotFound) {
+		http.Error(writer, fmt.Sprintf("Job %v not found", id), http.StatusNotFound)
+		return
 	}
-	if len(candidates) == 0 {
-		handler.Log.WithTransactionID(tid).Infof("Content type candidates are empty. Using all supported ones: %v", handler.ConceptTypes)
-		candidates = handler.ConceptTypes
+	if errors.Is(err, export.ErrJobNotRunning) {
+		http.Error(writer, fmt.Sprintf("Job %v is not running, its status is %v", id, job.Status), http.StatusConflict)
+		return
 	}
-	return
-}
 
-func extractCandidateConceptTypesFromRequest(request *http.Request, log *logger.LogEntry) (candidates []string) {
-	var result map[string]interface{}
-	body, err := ioutil.ReadAll(request.Body)
+	tid := transactionidutils.GetTransactionIDFromRequest(request)
+	handler.Log.WithTransactionID(tid).Infof("Cancellation of job %v requested", id)
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusAccepted)
+	err = json.NewEncoder(writer).Encode(&job)
 	if err != nil {
-		log.WithError(err).Error("no valid POST body found, thus no candidate concept types to export")
+		msg := fmt.Sprintf(`Failed to write job %v to response writer: "%v"`, job.ID, err)
+		handler.Log.WithTransactionID(tid).Warn(msg)
+		fmt.Fprintf(writer, "{\"ID\": \"%v\"}", job.ID)
 		return
 	}
+}
 
-	if err = json.Unmarshal(body, &result); err != nil {
-		log.WithError(err).Error("no valid JSON body found, thus no candidate concept types to export")
+func (handler *RequestHandler) Export(writer http.ResponseWriter, request *http.Request) {
+	tid := transactionidutils.GetTransactionIDFromRequest(request)
+
+	req, fieldErr := decodeExportRequest(request.Body)
+	if fieldErr != nil {
+		handler.Log.WithTransactionID(tid).Infof("Invalid export request: %v", fieldErr)
+		writeRequestErrors(writer, []fieldError{*fieldErr})
 		return
 	}
-	log.Debugf("Parsing request body: %v", result)
-	cTypes, ok := result["conceptTypes"]
-	if !ok {
-		log.Infof("no conceptTypes field found in the JSON body, thus no candidate concept types to export.")
+	candidates, unsupported := handler.getCandidateConceptTypes(req.ConceptTypes, tid)
+	if len(candidates) == 0 {
+		writeRequestErrors(writer, []fieldError{{Field: "conceptTypes", Message: "has no supported concept types"}})
 		return
 	}
-	cTypesString, ok := cTypes.(string)
-	if ok {
-		candidates = strings.Split(cTypesString, " ")
-	} else {
-		log.WithError(err).Error("the conceptTypes field found in JSON body is not a string as expected.")
+	if len(unsupported) != 0 {
+		writeRequestErrors(writer, []fieldError{{Field: "conceptTypes", Message: "has unsupported concept types " + strings.Join(unsupported, ", ")}})
+		return
+	}
+	options, errs := req.options(handler.Exporter, candidates)
+	if len(errs) != 0 {
+		writeRequestErrors(writer, errs)
+		return
+	}
+	// a delta export without a since takes the changes since the latest successful export of the concept types
+	if options.Since == nil && req.Delta {
+		since, found := handler.Exporter.GetLastSuccessfulJobStart(candidates, options)
+		if !found {
+			writeRequestErrors(writer, []fieldError{{Field: "delta", Message: "has no successful job to export the changes since"}})
+			return
+		}
+		options.Since = since
+	}
+	created, err := handler.Exporter.TryCreateJob(candidates, options, "")
+	if err != nil {
+		http.Error(writer, "There are already running export jobs. Please wait them to finish", http.StatusBadRequest)
+		return
+	}
+	// the response has the job as created, before it starts running
+	job, _ := handler.Exporter.GetJob(created.ID)
+	go handler.Exporter.RunFullExport(created, tid)
+	writer.Header().Add("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusAccepted)
+
+	err = json.NewEncoder(writer).Encode(&job)
+	if err != nil {
+		msg := fmt.Sprintf(`Failed to write job %v to response writer: "%v"`, job.ID, err)
+		handler.Log.WithTransactionID(tid).Warnf(msg)
+		fmt.Fprintf(writer, "{\"ID\": \"%v\"}", job.ID)
+		return
+	}
+}
+
+// getCandidateConceptTypes returns the supported requested concept types and the unsupported ones,
+// or all the supported ones if none was requested
+func (handler *RequestHandler) getCandidateConceptTypes(requested []string, tid string) (candidates, unsupported []string) {
+	if len(requested) == 0 {
+		handler.Log.WithTransactionID(tid).Infof("Content type candidates are empty. Using all supported ones: %v", handler.ConceptTypes)
+		return handler.ConceptTypes, nil
+	}
+	for _, cand := range requested {
+		if slices.Contains(handler.ConceptTypes, cand) {
+			candidates = append(candidates, cand)
+		} else {
+			unsupported = append(unsupported, cand)
+		}
 	}
 	return
 }